@@ -0,0 +1,240 @@
+package ami
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// eventBuffer is the number of events kept while nobody reads them. Further
+// events are dropped, so clients used only for actions never block.
+const eventBuffer = 1024
+
+// ErrClosed is returned by actions sent on a closed connection
+var ErrClosed = errors.New("AMI connection closed")
+
+// Message is an AMI response or event, as a set of header fields. Repeated
+// fields, such as the Output lines of a Command response, are joined with
+// new lines.
+type Message map[string]string
+
+// Client is a minimal Asterisk Manager Interface client. A background reader
+// dispatches responses to the pending actions and events to Events().
+type Client struct {
+	conn     net.Conn
+	reader   *textproto.Reader
+	timeout  time.Duration
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	pending  map[string]chan Message
+	actionID int
+	events   chan Message
+	done     chan struct{}
+	err      error
+}
+
+// Dial connects to the AMI at address and logs in with username and secret.
+// events is the event mask requested at login, e.g. "system" or "off".
+func Dial(address, username, secret string, timeout time.Duration, events string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to AMI: %w", err)
+	}
+
+	c := &Client{
+		conn:    conn,
+		reader:  textproto.NewReader(bufio.NewReader(conn)),
+		timeout: timeout,
+		pending: make(map[string]chan Message),
+		events:  make(chan Message, eventBuffer),
+		done:    make(chan struct{}),
+	}
+
+	// The first line is the banner, e.g. "Asterisk Call Manager/5.0.1"
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	banner, err := c.reader.ReadLine()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read AMI banner: %w", err)
+	}
+	if !strings.HasPrefix(banner, "Asterisk Call Manager") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected AMI banner: %q", banner)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	go c.readLoop()
+
+	resp, err := c.Action("Login", Message{"Username": username, "Secret": secret, "Events": events})
+	if err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	if !strings.EqualFold(resp["Response"], "Success") {
+		c.conn.Close()
+		return nil, fmt.Errorf("AMI login failed: %s", resp["Message"])
+	}
+	return c, nil
+}
+
+// Action sends an action and waits for its response
+func (c *Client) Action(action string, fields Message) (Message, error) {
+	c.mu.Lock()
+	c.actionID++
+	id := strconv.Itoa(c.actionID)
+	response := make(chan Message, 1)
+	c.pending[id] = response
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Action: %s\r\nActionID: %s\r\n", action, id)
+	for key, value := range fields {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	b.WriteString("\r\n")
+
+	c.writeMu.Lock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write([]byte(b.String()))
+	c.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send AMI action %s: %w", action, err)
+	}
+
+	select {
+	case msg := <-response:
+		return msg, nil
+	case <-c.done:
+		return nil, fmt.Errorf("failed to read AMI response to %s: %w", action, c.Err())
+	case <-time.After(c.timeout):
+		return nil, fmt.Errorf("timeout waiting for AMI response to %s", action)
+	}
+}
+
+// List sends an action that returns a list of events, such as
+// PJSIPShowEndpoints, and collects the events of the list until its complete
+// event. The other events are discarded, so it is meant for clients logged in
+// with the "off" event mask.
+func (c *Client) List(action string, fields Message) ([]Message, error) {
+	resp, err := c.Action(action, fields)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(resp["Response"], "Success") {
+		return nil, fmt.Errorf("AMI action %s failed: %s", action, resp["Message"])
+	}
+
+	var list []Message
+	for {
+		select {
+		case msg := <-c.events:
+			if msg["ActionID"] != resp["ActionID"] {
+				continue
+			}
+			if strings.EqualFold(msg["EventList"], "Complete") {
+				return list, nil
+			}
+			list = append(list, msg)
+		case <-c.done:
+			return nil, fmt.Errorf("failed to read AMI list of %s: %w", action, c.Err())
+		case <-time.After(c.timeout):
+			return nil, fmt.Errorf("timeout waiting for AMI list of %s", action)
+		}
+	}
+}
+
+// Events returns the channel receiving the AMI events
+func (c *Client) Events() <-chan Message {
+	return c.events
+}
+
+// Done is closed when the connection is lost or closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection was closed
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close logs off and closes the connection
+func (c *Client) Close() error {
+	select {
+	case <-c.done:
+	default:
+		c.Action("Logoff", nil)
+	}
+	return c.conn.Close()
+}
+
+// readLoop reads messages until the connection fails
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			c.err = err
+			if errors.Is(err, net.ErrClosed) {
+				c.err = ErrClosed
+			}
+			close(c.done)
+			return
+		}
+
+		if _, ok := msg["Event"]; ok {
+			select {
+			case c.events <- msg:
+			default:
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		response, ok := c.pending[msg["ActionID"]]
+		c.mu.Unlock()
+		if ok {
+			response <- msg
+		}
+	}
+}
+
+// readMessage reads one message terminated by an empty line
+func (c *Client) readMessage() (Message, error) {
+	msg := Message{}
+	for {
+		line, err := c.reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			if len(msg) == 0 {
+				continue
+			}
+			return msg, nil
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if previous, exists := msg[key]; exists {
+				value = previous + "\n" + value
+			}
+			msg[key] = value
+		}
+	}
+}
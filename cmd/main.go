@@ -1,22 +1,122 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"log"
+	"math"
 	"os"
-	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/lordbasex/parsewatchdog/flapping"
+	"github.com/lordbasex/parsewatchdog/group"
+	"github.com/lordbasex/parsewatchdog/inventory"
+	"github.com/lordbasex/parsewatchdog/latency"
 	"github.com/lordbasex/parsewatchdog/notification"
+	"github.com/lordbasex/parsewatchdog/remediation"
+	"github.com/lordbasex/parsewatchdog/security"
+	"github.com/lordbasex/parsewatchdog/source"
 )
 
 var lastAlertTimestamps = make(map[string]struct{})
 
+// incident keeps an alerted mass disconnection open until all of its
+// extensions become reachable again
+type incident struct {
+	alert      *notification.Alert
+	pending    map[string]struct{}
+	openedAt   time.Time
+	remediated bool
+}
+
+var openIncidents = make(map[string]*incident)
+
+var hostname string
+
+// parser holds the detection rules, with the threshold and window of each one
+var parser *source.Parser
+
+// groups are the endpoint groups alerted on their own
+var groups []*group.Group
+
+// endpoints is the inventory of known endpoints, used by the percentage
+// thresholds
+var endpoints *inventory.Inventory
+
+// remediator runs the remediation actions of incidents that persist
+var remediator *remediation.Remediator
+
+// tracker counts the failed authentication attempts per source IP
+var tracker *security.Tracker
+
+// blockAlerts are the brute-force alerts of the blocked IPs, resolved when
+// their block expires
+var blockAlerts = make(map[string]*notification.Alert)
+
+// monitor keeps the qualify RTTs of the endpoints
+var monitor *latency.Monitor
+
+// detector counts the state transitions of the endpoints to find the
+// flapping ones
+var detector *flapping.Detector
+
+// latencyAlert is the open latency degradation alert, if any
+var latencyAlert *notification.Alert
+
+// lastUnreachable is the unreachable count last published as PBX status
+var lastUnreachable = -1
+
 const defaultConfigPath = "/etc/parsewatchdog.conf"
 const defaultConfigContent = `
+[general]
+language=en
+translations_dir=/etc/parsewatchdog/lang
+critical_threshold=10
+
+[input]
+sources=file
+log_file=/var/log/asterisk/full
+
+[rules]
+threshold=2
+window=0
+severity=warning
+
+[inventory]
+sources=observed
+endpoints=
+refresh=3600
+
+[trunks]
+names=
+severity=critical
+
+[security]
+enabled=false
+threshold=10
+window=60
+block_time=3600
+blocklist_file=
+ignore=127.0.0.1
+severity=warning
+
+[latency]
+enabled=false
+threshold=300
+percentile=95
+window=300
+percent=30
+min_endpoints=5
+
+[flapping]
+enabled=false
+transitions=6
+window=3600
+digest_interval=3600
+severity=info
+
 [smtp]
 enabled=false
 host=smtp.gmail.com
@@ -48,6 +148,115 @@ queue=parsewatchdog_notifications
 enabled=false
 webhook_url=https://hooks.slack.com/services/XXXXXXXXXXX/XXXXXXXXXXXX/XXXXXXXXXXXXXXXXXXXXXXXX
 
+[teams]
+enabled=false
+webhook_url=https://example.webhook.office.com/webhookb2/XXXXXXXX
+
+[discord]
+enabled=false
+webhook_url=https://discord.com/api/webhooks/XXXXXXXX/XXXXXXXX
+username=ParseWatchdog
+
+[mattermost]
+enabled=false
+webhook_url=https://mattermost.example.com/hooks/XXXXXXXX
+channel=
+username=ParseWatchdog
+
+[pagerduty]
+enabled=false
+routing_key=your_integration_key
+severity_info=info
+severity_warning=warning
+severity_critical=critical
+
+[opsgenie]
+enabled=false
+api_key=your_opsgenie_api_key
+url=https://api.opsgenie.com
+team=
+tags=asterisk,parsewatchdog
+p1_threshold=50
+p2_threshold=20
+p3_threshold=10
+p4_threshold=5
+
+[sms]
+enabled=false
+provider=twilio
+recipients=+15551234567
+from=+15557654321
+max_length=160
+max_per_hour=20
+url=https://api.twilio.com
+account_sid=your_account_sid
+auth_token=your_auth_token
+
+[ami]
+host=127.0.0.1
+port=5038
+username=parsewatchdog
+secret=your_ami_secret
+
+[ari]
+url=http://127.0.0.1:8088
+username=parsewatchdog
+password=your_ari_password
+app=parsewatchdog
+
+[voice]
+enabled=false
+method=callfile
+numbers=5551234567
+channel=PJSIP/%s@provider_trunk
+callerid="ParseWatchdog" <1000>
+context=parsewatchdog-alert
+sound=custom/parsewatchdog-alert
+min_severity=critical
+
+[syslog]
+enabled=false
+network=unix
+address=/dev/log
+facility=daemon
+app_name=parsewatchdog
+journald=auto
+
+[kafka]
+enabled=false
+brokers=192.168.0.10:9092
+topic=parsewatchdog.alerts
+
+[nats]
+enabled=false
+url=nats://192.168.0.10:4222
+subject=parsewatchdog.alerts
+jetstream=false
+
+[mqtt]
+enabled=false
+broker=tcp://192.168.0.10:1883
+version=3.1.1
+topic_prefix=parsewatchdog
+retain=true
+outage_threshold=10
+
+[exec]
+enabled=false
+command=/usr/local/bin/parsewatchdog-alert.sh
+working_dir=/
+timeout=30
+
+[remediation]
+enabled=false
+after=60
+cooldown=600
+max_per_day=3
+
+[remediation.pjsip_reload]
+type=ami
+command=pjsip reload
+
 [debug]
 debug_level=1
 `
@@ -69,27 +278,108 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	hostname, err = os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
 	fmt.Printf("ParseWatchdog Pid(%d)\n", os.Getpid())
 	fmt.Printf("\n [*] Version: %s (%s)", config.Version, config.DaemonGitBuild)
 	fmt.Printf("\n [*] Build Date: %s \n\n", config.DaemonGitBuildDate)
 
-	filePath := "/var/log/asterisk/full"
-	file, err := os.Open(filePath)
+	// Compile and validate the detection rules
+	parser, err = source.NewParser(cfg)
+	if err != nil {
+		log.Fatalf("Error loading rules: %v", err)
+	}
+
+	groups, err = group.Load(cfg)
 	if err != nil {
-		log.Fatalf("Error opening log file: %v", err)
+		log.Fatalf("Error loading groups: %v", err)
+	}
+	for _, gc := range cfg.Groups {
+		if err := notification.ValidateChannels(cfg, gc.Channels); err != nil {
+			log.Fatalf("Error loading groups: group %s: %v", gc.Name, err)
+		}
 	}
-	defer file.Close()
 
-	// Start from the end of the file
-	file.Seek(0, os.SEEK_END)
+	if err := notification.ValidateRoutes(cfg); err != nil {
+		log.Fatalf("Error loading routes: %v", err)
+	}
+
+	if err := notification.ValidateWebhooks(cfg); err != nil {
+		log.Fatalf("Error loading webhooks: %v", err)
+	}
+
+	endpoints = inventory.New(cfg, parser.Tenant)
+	if endpoints.Queries() {
+		go refreshInventory(cfg)
+	}
+
+	// Start the configured event sources (log file, AMI, ...)
+	events := make(chan source.Event, 4096)
+	for _, name := range cfg.Input.Sources {
+		src, err := source.New(cfg, parser, name)
+		if err != nil {
+			log.Fatalf("Error starting %s source: %v", name, err)
+		}
+		go src.Run(events)
+	}
+
+	remediator = remediation.New(cfg)
+
+	if cfg.Security.Enabled {
+		tracker, err = security.New(cfg)
+		if err != nil {
+			log.Fatalf("Error loading security settings: %v", err)
+		}
+		// Keep the blocks of the previous run, the blocklist is written
+		// again only when some of them are gone
+		stale, err := tracker.LoadBlocklist(time.Now())
+		if err != nil {
+			log.Fatalf("Error loading blocklist: %v", err)
+		}
+		if stale {
+			if err := tracker.WriteBlocklist(); err != nil {
+				log.Fatalf("Error writing blocklist: %v", err)
+			}
+		}
+		if blocked := tracker.Blocked(); len(blocked) > 0 {
+			logMessage(cfg, 1, fmt.Sprintf("Blocks restored for %s", strings.Join(blocked, ", ")))
+		}
+	}
+
+	if cfg.Latency.Enabled {
+		monitor = latency.New(cfg)
+	}
+	if cfg.Flapping.Enabled {
+		detector = flapping.New(cfg)
+	}
 
 	// Monitor at regular intervals
 	for {
-		checkLogForUnreachable(file, cfg)
+		checkForUnreachable(drainEvents(events), cfg)
+		checkLatency(cfg)
+		sendFlappingDigest(cfg)
+		remediateIncidents(cfg)
+		expireBlocks(cfg)
 		time.Sleep(1 * time.Second)
 	}
 }
 
+// drainEvents returns the events received since the last call
+func drainEvents(events <-chan source.Event) []source.Event {
+	var batch []source.Event
+	for {
+		select {
+		case event := <-events:
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+}
+
 func createDefaultConfig(path string, content string) error {
 	// Check if the config file already exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -116,53 +406,546 @@ func logMessage(cfg *config.Config, level int, message string) {
 	}
 }
 
-func checkLogForUnreachable(file *os.File, cfg *config.Config) {
-	scanner := bufio.NewScanner(file)
-	unreachableEvents := make(map[string][]string)
+// cluster collects the unreachable entities reported by a rule for a tenant
+// within the rule window, starting at the first of them
+type cluster struct {
+	start     time.Time
+	timestamp string
+	entities  []string
+	seen      map[string]struct{}
+	incident  string
+}
+
+// clusters holds the current cluster of every scope
+var clusters = make(map[string]*cluster)
+
+// checkForUnreachable groups the Unreachable events of every rule or endpoint
+// group within its window, alerts when its threshold is reached and resolves the
+// incidents whose extensions recovered
+func checkForUnreachable(events []source.Event, cfg *config.Config) {
+	// Events are applied in log order, so an extension that recovered before
+	// going down again in the same batch stays pending
+	for _, event := range events {
+		if event.Kind == source.KindAuthFailure {
+			authFailure(cfg, event)
+			continue
+		}
+
+		trunk, isTrunk := trunkOf(cfg, event)
+		if event.Kind == source.KindRegistration && !isTrunk {
+			logMessage(cfg, 2, fmt.Sprintf("Registration %s matches no trunk, ignored", event.Entity))
+			continue
+		}
+
+		switch event.State {
+		case source.StateAcknowledged:
+			acknowledgeIncident(cfg, event.Entity, event.Detail)
+		case source.StateReachable:
+			if isTrunk {
+				resolveExtension(cfg, trunk)
+				continue
+			}
+			endpoints.Observe(event.Entity, event.Tenant)
+			if monitor != nil && event.RTT > 0 {
+				monitor.Add(event.Entity, event.RTT, time.Now())
+			}
+			if detector != nil {
+				detector.Record(event.Entity, event.State, eventTime(event))
+			}
+			resolveExtension(cfg, event.Entity)
+		case source.StateUnreachable:
+			// Trunks are alerted on their own and never counted as phones
+			if isTrunk {
+				trunkDown(cfg, trunk, event)
+				continue
+			}
+			endpoints.Observe(event.Entity, event.Tenant)
+			if monitor != nil {
+				monitor.Remove(event.Entity)
+			}
+			// Flapping endpoints are reported in the digest only, as they
+			// would trigger false mass disconnections
+			if detector != nil && detector.Record(event.Entity, event.State, eventTime(event)) {
+				logMessage(cfg, 2, fmt.Sprintf("Endpoint %s is flapping, not counted as disconnected", event.Entity))
+				continue
+			}
+			addUnreachable(cfg, parser.Rule(event.Rule), event)
+		}
+	}
+
+	publishStatus(cfg)
+}
+
+// scope is where the unreachable entities are counted together: a rule and
+// tenant, or an endpoint group
+type scope struct {
+	key    string
+	name   string
+	tenant string
+	group  string
+	window time.Duration
+	// limit and percent are the threshold of the scope, see threshold
+	limit   int
+	percent float64
+	// size returns the number of endpoints of the scope in the inventory
+	size func() int
+	// severity of the alerts, raised to critical from critical entities
+	severity string
+	critical int
+	// covered, when set, reports whether an entity is already alerted by
+	// another scope. Those entities count toward the threshold but are left
+	// out of the alerts of the scope.
+	covered func(entity string) bool
+}
+
+// addUnreachable adds the entity of an Unreachable event to the clusters of
+// its endpoint groups and of its rule and tenant
+func addUnreachable(cfg *config.Config, rule *source.Rule, event source.Event) {
+	at := eventTime(event)
+
+	// Each group is counted and alerted on its own, before the rule so the
+	// group alerts take precedence
+	for _, g := range group.Of(groups, event.Entity) {
+		addToCluster(cfg, scope{
+			key:     "group|" + g.Name,
+			name:    "group " + g.Name,
+			group:   g.Name,
+			window:  g.Window,
+			limit:   g.Threshold,
+			percent: g.ThresholdPercent,
+			size: func() int {
+				return endpoints.Count(func(entity, _ string) bool { return g.Contains(entity) })
+			},
+			severity: g.Severity,
+			critical: g.CriticalThreshold,
+		}, event, at)
+	}
+
+	tenant := event.Tenant
+	if tenant == "" {
+		tenant = parser.Tenant(event.Entity)
+	}
+	addToCluster(cfg, scope{
+		key:      rule.Name + "|" + tenant,
+		name:     "rule " + rule.Name,
+		tenant:   tenant,
+		window:   rule.Window,
+		limit:    rule.Threshold,
+		percent:  rule.ThresholdPercent,
+		size:     func() int { return endpoints.Size(tenant) },
+		severity: rule.Severity,
+		critical: rule.CriticalThreshold,
+		covered:  inGroupIncident,
+	}, event, at)
+}
+
+// inGroupIncident reports whether entity is still disconnected in an open
+// group incident
+func inGroupIncident(entity string) bool {
+	for _, inc := range openIncidents {
+		if inc.alert.Group == "" {
+			continue
+		}
+		if _, ok := inc.pending[entity]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addToCluster adds the entity to the cluster of the scope and alerts once
+// the cluster reaches the scope threshold. Entities joining a cluster already
+// alerted are added to its open incident, and a new cluster is started once
+// that incident is resolved.
+func addToCluster(cfg *config.Config, s scope, event source.Event, at time.Time) {
+	c := clusters[s.key]
+	if c != nil && c.incident != "" {
+		if _, open := openIncidents[c.incident]; !open {
+			c = nil
+		}
+	}
+	if c == nil || at.Sub(c.start).Abs() > s.window {
+		c = &cluster{start: at, timestamp: event.Timestamp, seen: make(map[string]struct{})}
+		clusters[s.key] = c
+	}
+	if _, ok := c.seen[event.Entity]; ok {
+		// An entity of the open incident that went down again is pending
+		// until it recovers again
+		if inc, ok := openIncidents[c.incident]; ok && slices.Contains(inc.alert.Extensions, event.Entity) {
+			inc.pending[event.Entity] = struct{}{}
+		}
+		return
+	}
+	c.seen[event.Entity] = struct{}{}
+	c.entities = append(c.entities, event.Entity)
 
-	// Updated regex to support both chan_sip (Peer) and pjsip (Endpoint), considering case sensitivity for UNREACHABLE
-	re := regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})] .+(Endpoint|Peer) ['"]?(\d+)['"]? is now (Unreachable|UNREACHABLE)`)
+	if c.incident != "" {
+		if s.covered != nil && s.covered(event.Entity) {
+			return
+		}
+		if inc, ok := openIncidents[c.incident]; ok {
+			inc.alert.Extensions = append(inc.alert.Extensions, event.Entity)
+			inc.pending[event.Entity] = struct{}{}
+		}
+		return
+	}
+	size := s.size()
+	if len(c.entities) < threshold(s.limit, s.percent, size) {
+		return
+	}
 
-	logMessage(cfg, 2, "Incremental log file reading...")
+	// Leave out the entities already alerted by another scope
+	var extensions []string
+	for _, entity := range c.entities {
+		if s.covered == nil || !s.covered(entity) {
+			extensions = append(extensions, entity)
+		}
+	}
+	if len(extensions) == 0 {
+		return
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		match := re.FindStringSubmatch(line)
-		if match != nil {
-			timestampStr := match[1]
-			extension := match[3]
+	timestamp := c.timestamp
+	logMessage(cfg, 1, fmt.Sprintf("Mass disconnection detected by %s at %s: %d extensions%s", s.name, timestamp, len(c.entities), tenantSuffix(s.tenant)))
 
-			logMessage(cfg, 2, fmt.Sprintf("Reading log line: %s", line))
+	// Check if the timestamp has already been registered for this scope
+	key := s.key + "|" + timestamp
+	if _, alreadyAlerted := lastAlertTimestamps[key]; alreadyAlerted {
+		logMessage(cfg, 2, fmt.Sprintf("Alert already sent for timestamp %s, skipping...", timestamp))
+		return
+	}
 
-			// Process only "now Unreachable/UNREACHABLE" events
-			unreachableEvents[timestampStr] = append(unreachableEvents[timestampStr], extension)
+	// Register new timestamp in lastAlertTimestamps
+	logMessage(cfg, 2, fmt.Sprintf("Registering alert for timestamp %s", timestamp))
+	lastAlertTimestamps[key] = struct{}{}
+
+	// Generate and send alert
+	alert := &notification.Alert{
+		ID:         incidentID(timestamp),
+		Kind:       notification.KindMassDisconnection,
+		Host:       hostname,
+		Timestamp:  timestamp,
+		Extensions: extensions,
+		Severity:   s.severity,
+		Tenant:     s.tenant,
+		Group:      s.group,
+		Observed:   size,
+	}
+	for _, suffix := range []string{s.tenant, s.group} {
+		if suffix != "" {
+			alert.ID += "-" + suffix
 		}
 	}
+	if _, exists := openIncidents[alert.ID]; exists {
+		alert.ID += "-" + strings.ReplaceAll(s.name, " ", "-")
+	}
+	if len(extensions) >= s.critical {
+		alert.Severity = notification.SeverityCritical
+	}
+	notification.NotifyAll(cfg, alert)
+	logMessage(cfg, 1, fmt.Sprintf("Alert sent: %d extensions disconnected at %s", len(extensions), timestamp))
 
-	for timestamp, extensions := range unreachableEvents {
-		if len(extensions) > 1 {
-			logMessage(cfg, 1, fmt.Sprintf("Mass disconnection detected at %s: %d extensions", timestamp, len(extensions)))
+	// Keep the incident open until its extensions recover
+	pending := make(map[string]struct{}, len(extensions))
+	for _, extension := range extensions {
+		pending[extension] = struct{}{}
+	}
+	openIncidents[alert.ID] = &incident{alert: alert, pending: pending, openedAt: time.Now()}
+	c.incident = alert.ID
+}
 
-			// Check if the timestamp has already been registered
-			if _, alreadyAlerted := lastAlertTimestamps[timestamp]; alreadyAlerted {
-				logMessage(cfg, 2, fmt.Sprintf("Alert already sent for timestamp %s, skipping...", timestamp))
-				continue
+// threshold returns the number of entities from which an alert is sent,
+// raised to percent of the size endpoints of the inventory when set
+func threshold(limit int, percent float64, size int) int {
+	if percent == 0 {
+		return limit
+	}
+	return max(limit, int(math.Ceil(percent*float64(size)/100)))
+}
+
+// refreshInventory queries the endpoints through the AMI every refresh
+// interval
+func refreshInventory(cfg *config.Config) {
+	for {
+		if count, err := endpoints.Refresh(); err != nil {
+			logMessage(cfg, 1, fmt.Sprintf("Error querying the endpoint inventory: %v", err))
+		} else {
+			logMessage(cfg, 2, fmt.Sprintf("Endpoint inventory: %d endpoints found through the AMI", count))
+		}
+		time.Sleep(time.Duration(cfg.Inventory.Refresh) * time.Second)
+	}
+}
+
+// trunkOf returns the configured trunk an event refers to. Registration
+// events are matched against the registration URIs of the trunks, or their
+// names when no URI is configured.
+func trunkOf(cfg *config.Config, event source.Event) (string, bool) {
+	if event.Kind == source.KindRegistration {
+		for _, trunk := range cfg.Trunks.Names {
+			if match, ok := cfg.Trunks.Registrations[trunk]; ok && match != "" && strings.Contains(event.Entity, match) {
+				return trunk, true
 			}
+		}
+	}
+	for _, trunk := range cfg.Trunks.Names {
+		if event.Entity == trunk {
+			return trunk, true
+		}
+	}
+	return "", false
+}
+
+// trunkDown alerts immediately when a trunk becomes unreachable or fails to
+// register, unless an incident is already open for it
+func trunkDown(cfg *config.Config, trunk string, event source.Event) {
+	for _, inc := range openIncidents {
+		if _, ok := inc.pending[trunk]; ok && inc.alert.Type() == notification.KindTrunkDown {
+			logMessage(cfg, 2, fmt.Sprintf("Trunk %s already down, skipping...", trunk))
+			return
+		}
+	}
+
+	alert := &notification.Alert{
+		ID:         incidentID(event.Timestamp) + "-" + trunk,
+		Kind:       notification.KindTrunkDown,
+		Host:       hostname,
+		Timestamp:  event.Timestamp,
+		Extensions: []string{trunk},
+		Severity:   cfg.Trunks.Severity,
+		Reason:     notification.ReasonUnreachable,
+	}
+	if event.Kind == source.KindRegistration {
+		alert.Reason = notification.ReasonRegistration
+		alert.Detail = event.Detail
+	}
+	notification.NotifyAll(cfg, alert)
+	logMessage(cfg, 1, fmt.Sprintf("Trunk down alert sent: %s (%s) at %s", trunk, alert.Reason, event.Timestamp))
+
+	openIncidents[alert.ID] = &incident{alert: alert, pending: map[string]struct{}{trunk: {}}, openedAt: time.Now()}
+}
+
+// authFailure records a failed authentication attempt and, when its source
+// IP reaches the rule threshold, blocks it and sends a brute-force alert
+func authFailure(cfg *config.Config, event source.Event) {
+	if tracker == nil {
+		return
+	}
+
+	rule := parser.Rule(event.Rule)
+	at := eventTime(event)
+	attempts, blocked := tracker.Record(event.Entity, at, rule.Threshold, rule.Window)
+	logMessage(cfg, 2, fmt.Sprintf("Failed authentication from %s (%s): %d attempts", event.Entity, event.Detail, attempts))
+	if !blocked {
+		return
+	}
+
+	// Always logged, so fail2ban can also act on the watchdog log
+	logMessage(cfg, 0, fmt.Sprintf("ParseWatchdog BLOCK %s after %d failed authentication attempts", event.Entity, attempts))
+	if err := tracker.WriteBlocklist(); err != nil {
+		log.Println("Error writing blocklist:", err)
+	}
+
+	alert := &notification.Alert{
+		ID:         incidentID(event.Timestamp) + "-" + event.Entity,
+		Kind:       notification.KindBruteForce,
+		Host:       hostname,
+		Timestamp:  event.Timestamp,
+		Extensions: []string{event.Entity},
+		Severity:   cfg.Security.Severity,
+		Detail:     event.Detail,
+		Attempts:   attempts,
+	}
+	notification.NotifyAll(cfg, alert)
+	blockAlerts[event.Entity] = alert
+}
+
+// checkLatency alerts when the share of endpoints with a degraded qualify RTT
+// reaches the configured percentage, and resolves the alert once it drops
+func checkLatency(cfg *config.Config) {
+	if monitor == nil {
+		return
+	}
+
+	degraded, observed := monitor.Check(time.Now())
+	breach := observed >= cfg.Latency.MinEndpoints && len(degraded)*100 >= cfg.Latency.Percent*observed
+
+	switch {
+	case breach && latencyAlert == nil:
+		timestamp := time.Now().Format(source.TimestampLayout)
+		latencyAlert = &notification.Alert{
+			ID:         incidentID(timestamp) + "-latency",
+			Kind:       notification.KindLatency,
+			Host:       hostname,
+			Timestamp:  timestamp,
+			Extensions: degraded,
+			Severity:   cfg.Latency.Severity,
+			Detail:     fmt.Sprintf("p%d RTT > %dms", cfg.Latency.Percentile, cfg.Latency.Threshold),
+			Observed:   observed,
+		}
+		notification.NotifyAll(cfg, latencyAlert)
+		logMessage(cfg, 1, fmt.Sprintf("Latency alert sent: %d of %d endpoints with %s", len(degraded), observed, latencyAlert.Detail))
+	case !breach && latencyAlert != nil:
+		resolved := *latencyAlert
+		resolved.Resolved = true
+		notification.NotifyResolved(cfg, &resolved)
+		logMessage(cfg, 1, fmt.Sprintf("Latency alert %s resolved: %d of %d endpoints degraded", latencyAlert.ID, len(degraded), observed))
+		latencyAlert = nil
+	}
+}
+
+// sendFlappingDigest sends the low priority digest of the endpoints that
+// flapped during the last digest interval
+func sendFlappingDigest(cfg *config.Config) {
+	if detector == nil {
+		return
+	}
+	entities := detector.Digest(time.Now())
+	if len(entities) == 0 {
+		return
+	}
 
-			// Register new timestamp in lastAlertTimestamps
-			logMessage(cfg, 2, fmt.Sprintf("Registering alert for timestamp %s", timestamp))
-			lastAlertTimestamps[timestamp] = struct{}{}
+	timestamp := time.Now().Format(source.TimestampLayout)
+	alert := &notification.Alert{
+		ID:         incidentID(timestamp) + "-flapping",
+		Kind:       notification.KindFlapping,
+		Host:       hostname,
+		Timestamp:  timestamp,
+		Extensions: entities,
+		Severity:   cfg.Flapping.Severity,
+		Detail:     fmt.Sprintf("%d+ state changes in %s", cfg.Flapping.Transitions, time.Duration(cfg.Flapping.Window)*time.Second),
+	}
+	notification.NotifyAll(cfg, alert)
+	logMessage(cfg, 1, fmt.Sprintf("Flapping digest sent: %s", strings.Join(entities, ", ")))
+}
 
-			// Generate and send alert
-			subject := fmt.Sprintf("Mass Disconnection Alert: %d extensions disconnected at %s", len(extensions), timestamp)
-			message := fmt.Sprintf("Mass disconnection detected at %s:\nTotal: %d extensions disconnected.\nExtensions: %s", timestamp, len(extensions), strings.Join(extensions, ", "))
-			notification.NotifyAll(cfg, subject, message)
-			logMessage(cfg, 1, fmt.Sprintf("Alert sent: %s", subject))
+// expireBlocks removes the expired blocks from the blocklist and resolves
+// their brute-force alerts
+func expireBlocks(cfg *config.Config) {
+	if tracker == nil {
+		return
+	}
+	expired := tracker.Expire(time.Now())
+	if len(expired) == 0 {
+		return
+	}
+	logMessage(cfg, 1, fmt.Sprintf("Block expired for %s", strings.Join(expired, ", ")))
+	if err := tracker.WriteBlocklist(); err != nil {
+		log.Println("Error writing blocklist:", err)
+	}
+
+	for _, ip := range expired {
+		alert, ok := blockAlerts[ip]
+		if !ok {
+			continue
 		}
+		resolved := *alert
+		resolved.Resolved = true
+		notification.NotifyResolved(cfg, &resolved)
+		delete(blockAlerts, ip)
+	}
+}
+
+// eventTime returns the time of an event, or the current time when its
+// timestamp cannot be parsed
+func eventTime(event source.Event) time.Time {
+	at, err := time.ParseInLocation(source.TimestampLayout, event.Timestamp, time.Local)
+	if err != nil {
+		return time.Now()
 	}
+	return at
+}
+
+// tenantSuffix formats the tenant for the log messages
+func tenantSuffix(tenant string) string {
+	if tenant == "" {
+		return ""
+	}
+	return " (tenant " + tenant + ")"
+}
+
+// remediateIncidents runs the remediation actions for incidents open for
+// longer than the configured delay and sends a follow-up notification with
+// the outcome. Each incident is remediated at most once.
+func remediateIncidents(cfg *config.Config) {
+	if !cfg.Remediation.Enabled || len(cfg.Remediation.Actions) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for id, inc := range openIncidents {
+		if inc.remediated || now.Sub(inc.openedAt) < time.Duration(cfg.Remediation.After)*time.Second {
+			continue
+		}
+		// Retried on the next check while the cooldown or the daily limit
+		// holds it back
+		if err := remediator.Allowed(now); err != nil {
+			logMessage(cfg, 2, fmt.Sprintf("Remediation postponed for incident %s: %v", id, err))
+			continue
+		}
+		inc.remediated = true
+
+		logMessage(cfg, 1, fmt.Sprintf("Running remediation for incident %s", id))
+		results := remediator.Run(now)
+		for _, result := range results {
+			logMessage(cfg, 1, fmt.Sprintf("Remediation %s success=%t", result.Name, result.Success))
+			logMessage(cfg, 2, fmt.Sprintf("Remediation %s output: %s", result.Name, result.Output))
+		}
+
+		// Informational follow-up of the same incident with the outcome
+		followUp := *inc.alert
+		followUp.Kind = notification.KindRemediation
+		followUp.Severity = notification.SeverityInfo
+		followUp.Remediation = results
+		notification.NotifyAll(cfg, &followUp)
+	}
+}
+
+// publishStatus publishes the number of extensions still unreachable in open
+// incidents whenever it changes
+func publishStatus(cfg *config.Config) {
+	unreachable := 0
+	for _, inc := range openIncidents {
+		unreachable += len(inc.pending)
+	}
+	if unreachable == lastUnreachable {
+		return
+	}
+	lastUnreachable = unreachable
+	notification.PublishStatus(cfg, hostname, unreachable)
+}
+
+// incidentID builds a stable identifier for the incident detected at timestamp
+func incidentID(timestamp string) string {
+	return fmt.Sprintf("%s-%s", hostname, strings.NewReplacer("-", "", ":", "", " ", "T").Replace(timestamp))
+}
+
+// acknowledgeIncident records who acknowledged an open incident
+func acknowledgeIncident(cfg *config.Config, id, by string) {
+	inc, ok := openIncidents[id]
+	if !ok {
+		logMessage(cfg, 2, fmt.Sprintf("Acknowledgement for unknown incident %s, ignoring...", id))
+		return
+	}
+	if by == "" {
+		by = "unknown"
+	}
+	inc.alert.AcknowledgedBy = by
+	logMessage(cfg, 1, fmt.Sprintf("Incident %s acknowledged by %s", id, by))
+}
+
+// resolveExtension removes a recovered extension from the open incidents and
+// sends the resolved notification once an incident has no pending extensions
+func resolveExtension(cfg *config.Config, extension string) {
+	for id, inc := range openIncidents {
+		delete(inc.pending, extension)
+		if len(inc.pending) > 0 {
+			continue
+		}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		logMessage(cfg, 2, fmt.Sprintf("Error reading log lines: %v", err))
+		resolved := *inc.alert
+		resolved.Resolved = true
+		notification.NotifyResolved(cfg, &resolved)
+		logMessage(cfg, 1, fmt.Sprintf("Incident %s resolved: all extensions are reachable again", id))
+		delete(openIncidents, id)
 	}
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/lordbasex/parsewatchdog/group"
+	"github.com/lordbasex/parsewatchdog/inventory"
+	"github.com/lordbasex/parsewatchdog/source"
+)
+
+// newTestConfig resets the watchdog state and returns a configuration that
+// alerts from 2 unreachable endpoints within 300 seconds, with no
+// notification channel enabled
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Rules.Threshold = 2
+	cfg.Rules.Window = 300
+
+	var err error
+	if parser, err = source.NewParser(cfg); err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	if groups, err = group.Load(cfg); err != nil {
+		t.Fatalf("group.Load: %v", err)
+	}
+	endpoints = inventory.New(cfg, parser.Tenant)
+	hostname = "pbx"
+	clusters = make(map[string]*cluster)
+	openIncidents = make(map[string]*incident)
+	lastAlertTimestamps = make(map[string]struct{})
+	lastUnreachable = -1
+	return cfg
+}
+
+// stateEvent builds an endpoint state event offset seconds after 10:00:00
+func stateEvent(entity, state string, offset int) source.Event {
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local).Add(time.Duration(offset) * time.Second)
+	return source.Event{
+		Timestamp: at.Format(source.TimestampLayout),
+		Entity:    entity,
+		State:     state,
+		Kind:      source.KindEndpointState,
+	}
+}
+
+func TestClusterAfterResolvedIncident(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	checkForUnreachable([]source.Event{
+		stateEvent("1001", source.StateUnreachable, 0),
+		stateEvent("1002", source.StateUnreachable, 1),
+	}, cfg)
+	if len(openIncidents) != 1 {
+		t.Fatalf("open incidents = %d, want 1", len(openIncidents))
+	}
+	checkForUnreachable([]source.Event{
+		stateEvent("1001", source.StateReachable, 30),
+		stateEvent("1002", source.StateReachable, 31),
+	}, cfg)
+	if len(openIncidents) != 0 {
+		t.Fatalf("open incidents = %d after recovery, want 0", len(openIncidents))
+	}
+
+	// New disconnections within the window of the resolved cluster
+	checkForUnreachable([]source.Event{
+		stateEvent("1003", source.StateUnreachable, 120),
+		stateEvent("1004", source.StateUnreachable, 121),
+		stateEvent("1005", source.StateUnreachable, 122),
+	}, cfg)
+	if len(openIncidents) != 1 {
+		t.Fatalf("open incidents = %d, want 1", len(openIncidents))
+	}
+	for _, inc := range openIncidents {
+		if got := len(inc.alert.Extensions); got != 3 {
+			t.Errorf("%d extensions %v, want 1003, 1004 and 1005", got, inc.alert.Extensions)
+		}
+	}
+}
+
+func TestClusterEntityDownAgain(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	checkForUnreachable([]source.Event{
+		stateEvent("1001", source.StateUnreachable, 0),
+		stateEvent("1002", source.StateUnreachable, 1),
+		stateEvent("1001", source.StateReachable, 10),
+		stateEvent("1001", source.StateUnreachable, 20),
+		stateEvent("1002", source.StateReachable, 30),
+	}, cfg)
+	if len(openIncidents) != 1 {
+		t.Fatalf("open incidents = %d while 1001 is down, want 1", len(openIncidents))
+	}
+	for _, inc := range openIncidents {
+		if _, ok := inc.pending["1001"]; !ok || len(inc.pending) != 1 {
+			t.Errorf("pending = %v, want 1001 only", inc.pending)
+		}
+	}
+
+	checkForUnreachable([]source.Event{stateEvent("1001", source.StateReachable, 40)}, cfg)
+	if len(openIncidents) != 0 {
+		t.Fatalf("open incidents = %d after recovery, want 0", len(openIncidents))
+	}
+}
@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
 	"gopkg.in/ini.v1"
 )
 
@@ -51,13 +56,347 @@ type SlackConfig struct {
 	WebhookURL string
 }
 
+type TeamsConfig struct {
+	Enabled    bool
+	WebhookURL string
+}
+
+type DiscordConfig struct {
+	Enabled    bool
+	WebhookURL string
+	Username   string
+}
+
+type MattermostConfig struct {
+	Enabled    bool
+	WebhookURL string
+	Channel    string
+	Username   string
+}
+
+type PagerDutyConfig struct {
+	Enabled          bool
+	RoutingKey       string
+	URL              string
+	SeverityInfo     string
+	SeverityWarning  string
+	SeverityCritical string
+}
+
+type OpsgenieConfig struct {
+	Enabled     bool
+	APIKey      string
+	URL         string
+	Team        string
+	Tags        []string
+	P1Threshold int
+	P2Threshold int
+	P3Threshold int
+	P4Threshold int
+}
+
+type SMSConfig struct {
+	Enabled     bool
+	Provider    string
+	Recipients  []string
+	From        string
+	MaxLength   int
+	MaxPerHour  int
+	URL         string
+	AccountSID  string
+	AuthToken   string
+	Method      string
+	ContentType string
+	Headers     map[string]string
+	Body        string
+}
+
+type AMIConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Secret   string
+}
+
+// Address returns the host:port of the Asterisk Manager Interface
+func (c AMIConfig) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+type ARIConfig struct {
+	URL      string
+	Username string
+	Password string
+	App      string
+}
+
+type VoiceConfig struct {
+	Enabled     bool
+	Method      string
+	Numbers     []string
+	Channel     string
+	CallerID    string
+	Context     string
+	Extension   string
+	Priority    int
+	Sound       string
+	MinSeverity string
+	SpoolDir    string
+	MaxRetries  int
+	RetryTime   int
+	WaitTime    int
+}
+
+type SyslogConfig struct {
+	Enabled     bool
+	Network     string
+	Address     string
+	Facility    string
+	AppName     string
+	TLSCA       string
+	TLSInsecure bool
+	Journald    string
+}
+
+type KafkaConfig struct {
+	Enabled  bool
+	Brokers  []string
+	Topic    string
+	TLS      bool
+	Username string
+	Password string
+}
+
+type NATSConfig struct {
+	Enabled   bool
+	URL       string
+	Subject   string
+	JetStream bool
+	CredsFile string
+	Username  string
+	Password  string
+}
+
+type MQTTConfig struct {
+	Enabled         bool
+	Broker          string
+	Version         string
+	ClientID        string
+	Username        string
+	Password        string
+	TLSCA           string
+	TLSInsecure     bool
+	TopicPrefix     string
+	QoS             int
+	Retain          bool
+	OutageThreshold int
+}
+
+type ExecConfig struct {
+	Enabled    bool
+	Command    string
+	WorkingDir string
+	Timeout    int
+}
+
+type RemediationAction struct {
+	Name    string
+	Type    string
+	Command string
+	Timeout int
+}
+
+type RemediationConfig struct {
+	Enabled   bool
+	After     int
+	Cooldown  int
+	MaxPerDay int
+	Actions   []RemediationAction
+}
+
+type WebhookConfig struct {
+	Name        string
+	Enabled     bool
+	URL         string
+	Method      string
+	ContentType string
+	Headers     map[string]string
+	Body        string
+	Resolved    bool
+}
+
+type InputConfig struct {
+	Sources            []string
+	LogFile            string
+	JournalUnits       []string
+	JournalIdentifiers []string
+	SyslogNetwork      string
+	SyslogAddress      string
+	// SyslogAllowedSources lists the networks (CIDR) the syslog source
+	// accepts messages from
+	SyslogAllowedSources []string
+}
+
+// RuleConfig is a log pattern turned into events. Pattern is a regular
+// expression with the named groups timestamp, entity and state.
+// ThresholdPercent, when set, raises Threshold to that percentage of the
+// endpoint inventory. Alerts have the Severity of the rule, raised to critical
+// from CriticalThreshold entities.
+type RuleConfig struct {
+	Name              string
+	Pattern           string
+	Kind              string
+	State             string
+	TimestampLayout   string
+	Threshold         int
+	ThresholdPercent  float64
+	Window            int
+	Severity          string
+	CriticalThreshold int
+}
+
+// RulesConfig holds the log rules and the default threshold and window, also
+// used by the sources that do not parse logs. TenantPattern extracts the
+// tenant of the endpoint names with the named group tenant.
+type RulesConfig struct {
+	Threshold         int
+	ThresholdPercent  float64
+	Window            int
+	Severity          string
+	CriticalThreshold int
+	TenantPattern     string
+	Rules             []RuleConfig
+}
+
+// GroupConfig is a group of endpoints, given by name, numeric range (e.g.
+// 1100-1199) or regular expression, alerted on its own with its threshold and
+// window, and the severity of its alerts like the rules. Channels restricts
+// its alerts to some notification channels, and the recipients of the email,
+// SMS and voice channels may be replaced.
+type GroupConfig struct {
+	Name              string
+	Endpoints         []string
+	Ranges            []string
+	Pattern           string
+	Threshold         int
+	ThresholdPercent  float64
+	Window            int
+	Severity          string
+	CriticalThreshold int
+	Channels          []string
+	SMTPRecipients    []string
+	SMSRecipients     []string
+	VoiceNumbers      []string
+}
+
+// RouteConfig sends the alerts matching all of its conditions to Channels.
+// Empty conditions match every alert. Hours is a time of day range such as
+// 08:00-18:00 and Days lists week days such as mon,tue. The routes are
+// evaluated in order and the first match wins, unless Continue is set.
+type RouteConfig struct {
+	Name       string
+	Severities []string
+	Kinds      []string
+	Groups     []string
+	Hours      string
+	Days       []string
+	Channels   []string
+	Continue   bool
+}
+
+// InventoryConfig lists the sources of the known endpoints: the observed
+// events, the configured Endpoints and the AMI PJSIPShowEndpoints action,
+// queried every Refresh seconds
+type InventoryConfig struct {
+	Sources   []string
+	Endpoints []string
+	Refresh   int
+}
+
+// TrunksConfig lists the SIP trunks alerted on their own. Registrations maps
+// a trunk name to a substring of its outbound registration URI.
+type TrunksConfig struct {
+	Names         []string
+	Registrations map[string]string
+	Severity      string
+}
+
+// SecurityConfig configures the detection of failed authentication attempts
+// per source IP and the blocklist written for the firewall
+type SecurityConfig struct {
+	Enabled       bool
+	Threshold     int
+	Window        int
+	BlockTime     int
+	BlocklistFile string
+	Ignore        []string
+	Severity      string
+}
+
+// LatencyConfig configures the qualify RTT degradation alerts. An endpoint
+// is degraded when the Percentile of its RTTs within Window seconds is above
+// Threshold milliseconds.
+type LatencyConfig struct {
+	Enabled      bool
+	Threshold    int
+	Percentile   int
+	Window       int
+	Samples      int
+	Percent      int
+	MinEndpoints int
+	Severity     string
+}
+
+// FlappingConfig configures the detection of endpoints changing state at
+// least Transitions times within Window seconds, reported in a digest every
+// DigestInterval seconds
+type FlappingConfig struct {
+	Enabled        bool
+	Transitions    int
+	Window         int
+	DigestInterval int
+	Severity       string
+}
+
+type GeneralConfig struct {
+	Language          string
+	TranslationsDir   string
+	CriticalThreshold int
+}
+
 type Config struct {
-	SMTP     SMTPConfig
-	Telegram TelegramConfig
-	API      APIConfig
-	Debug    DebugConfig
-	RabbitMQ RabbitMQConfig
-	Slack    SlackConfig
+	General     GeneralConfig
+	Input       InputConfig
+	Rules       RulesConfig
+	Groups      []GroupConfig
+	Routes      []RouteConfig
+	Inventory   InventoryConfig
+	Trunks      TrunksConfig
+	Security    SecurityConfig
+	Latency     LatencyConfig
+	Flapping    FlappingConfig
+	SMTP        SMTPConfig
+	Telegram    TelegramConfig
+	API         APIConfig
+	Debug       DebugConfig
+	RabbitMQ    RabbitMQConfig
+	Slack       SlackConfig
+	Teams       TeamsConfig
+	Discord     DiscordConfig
+	Mattermost  MattermostConfig
+	PagerDuty   PagerDutyConfig
+	Opsgenie    OpsgenieConfig
+	SMS         SMSConfig
+	AMI         AMIConfig
+	ARI         ARIConfig
+	Voice       VoiceConfig
+	Syslog      SyslogConfig
+	Kafka       KafkaConfig
+	NATS        NATSConfig
+	MQTT        MQTTConfig
+	Exec        ExecConfig
+	Remediation RemediationConfig
+	Webhooks    []WebhookConfig
 }
 
 // LoadConfig carga el archivo parsewatchdog.conf
@@ -69,6 +408,151 @@ func LoadConfig(filePath string) (*Config, error) {
 
 	config := &Config{}
 
+	// Leer configuración general
+	generalSection := cfg.Section("general")
+	config.General.Language = generalSection.Key("language").MustString("en")
+	config.General.TranslationsDir = generalSection.Key("translations_dir").MustString("/etc/parsewatchdog/lang")
+	config.General.CriticalThreshold = generalSection.Key("critical_threshold").MustInt(10)
+
+	// Leer configuración de las fuentes de eventos
+	inputSection := cfg.Section("input")
+	config.Input.Sources = inputSection.Key("sources").Strings(",")
+	if len(config.Input.Sources) == 0 {
+		config.Input.Sources = []string{"file"}
+	}
+	config.Input.LogFile = inputSection.Key("log_file").MustString("/var/log/asterisk/full")
+	config.Input.JournalUnits = inputSection.Key("journal_units").Strings(",")
+	config.Input.JournalIdentifiers = inputSection.Key("journal_identifiers").Strings(",")
+	config.Input.SyslogNetwork = inputSection.Key("syslog_network").In("udp", []string{"udp", "tcp"})
+	config.Input.SyslogAddress = inputSection.Key("syslog_address").MustString("127.0.0.1:5140")
+	config.Input.SyslogAllowedSources = inputSection.Key("syslog_allowed_sources").Strings(",")
+	if len(config.Input.SyslogAllowedSources) == 0 {
+		config.Input.SyslogAllowedSources = []string{"127.0.0.0/8", "::1/128"}
+	}
+
+	// Leer reglas de detección ([rules] y [rules.<nombre>])
+	rulesSection := cfg.Section("rules")
+	config.Rules.Threshold = rulesSection.Key("threshold").MustInt(2)
+	config.Rules.ThresholdPercent = rulesSection.Key("threshold_percent").MustFloat64(0)
+	config.Rules.Window = rulesSection.Key("window").MustInt(0)
+	config.Rules.Severity = rulesSection.Key("severity").In("warning", []string{"info", "warning", "critical"})
+	config.Rules.CriticalThreshold = rulesSection.Key("critical_threshold").MustInt(config.General.CriticalThreshold)
+	config.Rules.TenantPattern = rulesSection.Key("tenant_pattern").String()
+	for _, section := range rulesSection.ChildSections() {
+		config.Rules.Rules = append(config.Rules.Rules, RuleConfig{
+			Name:              strings.TrimPrefix(section.Name(), "rules."),
+			Pattern:           section.Key("pattern").String(),
+			Kind:              section.Key("kind").MustString("endpoint_state"),
+			State:             section.Key("state").String(),
+			TimestampLayout:   section.Key("timestamp_layout").String(),
+			Threshold:         section.Key("threshold").MustInt(config.Rules.Threshold),
+			ThresholdPercent:  section.Key("threshold_percent").MustFloat64(config.Rules.ThresholdPercent),
+			Window:            section.Key("window").MustInt(config.Rules.Window),
+			Severity:          section.Key("severity").In(config.Rules.Severity, []string{"info", "warning", "critical"}),
+			CriticalThreshold: section.Key("critical_threshold").MustInt(config.Rules.CriticalThreshold),
+		})
+	}
+
+	// Leer grupos de extensiones ([groups.<nombre>])
+	for _, section := range cfg.Section("groups").ChildSections() {
+		config.Groups = append(config.Groups, GroupConfig{
+			Name:              strings.TrimPrefix(section.Name(), "groups."),
+			Endpoints:         section.Key("endpoints").Strings(","),
+			Ranges:            section.Key("ranges").Strings(","),
+			Pattern:           section.Key("pattern").String(),
+			Threshold:         section.Key("threshold").MustInt(config.Rules.Threshold),
+			ThresholdPercent:  section.Key("threshold_percent").MustFloat64(config.Rules.ThresholdPercent),
+			Window:            section.Key("window").MustInt(config.Rules.Window),
+			Severity:          section.Key("severity").In(config.Rules.Severity, []string{"info", "warning", "critical"}),
+			CriticalThreshold: section.Key("critical_threshold").MustInt(config.Rules.CriticalThreshold),
+			Channels:          section.Key("channels").Strings(","),
+			SMTPRecipients:    section.Key("smtp_recipients").Strings(","),
+			SMSRecipients:     section.Key("sms_recipients").Strings(","),
+			VoiceNumbers:      section.Key("voice_numbers").Strings(","),
+		})
+	}
+
+	// Leer reglas de enrutamiento de alertas ([routes.<nombre>])
+	for _, section := range cfg.Section("routes").ChildSections() {
+		config.Routes = append(config.Routes, RouteConfig{
+			Name:       strings.TrimPrefix(section.Name(), "routes."),
+			Severities: section.Key("severity").Strings(","),
+			Kinds:      section.Key("kind").Strings(","),
+			Groups:     section.Key("group").Strings(","),
+			Hours:      section.Key("hours").String(),
+			Days:       section.Key("days").Strings(","),
+			Channels:   section.Key("channels").Strings(","),
+			Continue:   section.Key("continue").MustBool(false),
+		})
+	}
+
+	// Leer configuración del inventario de extensiones
+	inventorySection := cfg.Section("inventory")
+	config.Inventory.Sources = inventorySection.Key("sources").Strings(",")
+	if len(config.Inventory.Sources) == 0 {
+		config.Inventory.Sources = []string{"observed"}
+	}
+	config.Inventory.Endpoints = inventorySection.Key("endpoints").Strings(",")
+	config.Inventory.Refresh = inventorySection.Key("refresh").MustInt(3600)
+	if config.Inventory.Refresh <= 0 {
+		return nil, fmt.Errorf("inventory refresh must be at least 1 second")
+	}
+
+	// Leer configuración de troncales
+	trunksSection := cfg.Section("trunks")
+	config.Trunks.Names = trunksSection.Key("names").Strings(",")
+	config.Trunks.Severity = trunksSection.Key("severity").In("critical", []string{"info", "warning", "critical"})
+	config.Trunks.Registrations = make(map[string]string)
+	for _, key := range trunksSection.Keys() {
+		if name, ok := strings.CutPrefix(key.Name(), "registration."); ok {
+			config.Trunks.Registrations[name] = key.String()
+		}
+	}
+
+	// Leer configuración de seguridad
+	securitySection := cfg.Section("security")
+	config.Security.Enabled = securitySection.Key("enabled").MustBool(false)
+	config.Security.Threshold = securitySection.Key("threshold").MustInt(10)
+	config.Security.Window = securitySection.Key("window").MustInt(60)
+	config.Security.BlockTime = securitySection.Key("block_time").MustInt(3600)
+	config.Security.BlocklistFile = securitySection.Key("blocklist_file").String()
+	config.Security.Ignore = securitySection.Key("ignore").Strings(",")
+	config.Security.Severity = securitySection.Key("severity").In("warning", []string{"info", "warning", "critical"})
+
+	// Leer configuración de latencia
+	latencySection := cfg.Section("latency")
+	config.Latency.Enabled = latencySection.Key("enabled").MustBool(false)
+	config.Latency.Threshold = latencySection.Key("threshold").MustInt(300)
+	config.Latency.Percentile = latencySection.Key("percentile").MustInt(95)
+	config.Latency.Window = latencySection.Key("window").MustInt(300)
+	config.Latency.Samples = latencySection.Key("samples").MustInt(20)
+	if config.Latency.Samples < 1 {
+		return nil, fmt.Errorf("latency samples must be at least 1")
+	}
+	config.Latency.Percent = latencySection.Key("percent").MustInt(30)
+	config.Latency.MinEndpoints = latencySection.Key("min_endpoints").MustInt(5)
+	if config.Latency.Percent < 1 || config.Latency.Percent > 100 {
+		return nil, fmt.Errorf("latency percent must be between 1 and 100")
+	}
+	if config.Latency.MinEndpoints < 1 {
+		return nil, fmt.Errorf("latency min_endpoints must be at least 1")
+	}
+	config.Latency.Severity = latencySection.Key("severity").In("warning", []string{"info", "warning", "critical"})
+
+	// Leer configuración de flapping
+	flappingSection := cfg.Section("flapping")
+	config.Flapping.Enabled = flappingSection.Key("enabled").MustBool(false)
+	config.Flapping.Transitions = flappingSection.Key("transitions").MustInt(6)
+	config.Flapping.Window = flappingSection.Key("window").MustInt(3600)
+	if config.Flapping.Transitions < 2 {
+		return nil, fmt.Errorf("flapping transitions must be at least 2")
+	}
+	if config.Flapping.Window <= 0 {
+		return nil, fmt.Errorf("flapping window must be at least 1 second")
+	}
+	config.Flapping.DigestInterval = flappingSection.Key("digest_interval").MustInt(3600)
+	config.Flapping.Severity = flappingSection.Key("severity").In("info", []string{"info", "warning", "critical"})
+
 	// Leer configuración de SMTP
 	smtpSection := cfg.Section("smtp")
 	config.SMTP.Enabled = smtpSection.Key("enabled").MustBool(false)
@@ -109,5 +593,190 @@ func LoadConfig(filePath string) (*Config, error) {
 	config.Slack.Enabled = slackSection.Key("enabled").MustBool(false)
 	config.Slack.WebhookURL = slackSection.Key("webhook_url").String()
 
+	// Leer configuración de Microsoft Teams
+	teamsSection := cfg.Section("teams")
+	config.Teams.Enabled = teamsSection.Key("enabled").MustBool(false)
+	config.Teams.WebhookURL = teamsSection.Key("webhook_url").String()
+
+	// Leer configuración de Discord
+	discordSection := cfg.Section("discord")
+	config.Discord.Enabled = discordSection.Key("enabled").MustBool(false)
+	config.Discord.WebhookURL = discordSection.Key("webhook_url").String()
+	config.Discord.Username = discordSection.Key("username").MustString("ParseWatchdog")
+
+	// Leer configuración de Mattermost
+	mattermostSection := cfg.Section("mattermost")
+	config.Mattermost.Enabled = mattermostSection.Key("enabled").MustBool(false)
+	config.Mattermost.WebhookURL = mattermostSection.Key("webhook_url").String()
+	config.Mattermost.Channel = mattermostSection.Key("channel").String()
+	config.Mattermost.Username = mattermostSection.Key("username").MustString("ParseWatchdog")
+
+	// Leer configuración de PagerDuty
+	pagerDutySection := cfg.Section("pagerduty")
+	config.PagerDuty.Enabled = pagerDutySection.Key("enabled").MustBool(false)
+	config.PagerDuty.RoutingKey = pagerDutySection.Key("routing_key").String()
+	config.PagerDuty.URL = pagerDutySection.Key("url").MustString("https://events.pagerduty.com/v2/enqueue")
+	config.PagerDuty.SeverityInfo = pagerDutySection.Key("severity_info").In("info", []string{"critical", "error", "warning", "info"})
+	config.PagerDuty.SeverityWarning = pagerDutySection.Key("severity_warning").In("warning", []string{"critical", "error", "warning", "info"})
+	config.PagerDuty.SeverityCritical = pagerDutySection.Key("severity_critical").In("critical", []string{"critical", "error", "warning", "info"})
+
+	// Leer configuración de Opsgenie
+	opsgenieSection := cfg.Section("opsgenie")
+	config.Opsgenie.Enabled = opsgenieSection.Key("enabled").MustBool(false)
+	config.Opsgenie.APIKey = opsgenieSection.Key("api_key").String()
+	config.Opsgenie.URL = opsgenieSection.Key("url").MustString("https://api.opsgenie.com")
+	config.Opsgenie.Team = opsgenieSection.Key("team").String()
+	config.Opsgenie.Tags = opsgenieSection.Key("tags").Strings(",")
+	config.Opsgenie.P1Threshold = opsgenieSection.Key("p1_threshold").MustInt(50)
+	config.Opsgenie.P2Threshold = opsgenieSection.Key("p2_threshold").MustInt(20)
+	config.Opsgenie.P3Threshold = opsgenieSection.Key("p3_threshold").MustInt(10)
+	config.Opsgenie.P4Threshold = opsgenieSection.Key("p4_threshold").MustInt(5)
+
+	// Leer configuración de SMS
+	smsSection := cfg.Section("sms")
+	config.SMS.Enabled = smsSection.Key("enabled").MustBool(false)
+	config.SMS.Provider = smsSection.Key("provider").In("twilio", []string{"twilio", "http"})
+	config.SMS.Recipients = smsSection.Key("recipients").Strings(",")
+	config.SMS.From = smsSection.Key("from").String()
+	config.SMS.MaxLength = smsSection.Key("max_length").MustInt(160)
+	config.SMS.MaxPerHour = smsSection.Key("max_per_hour").MustInt(20)
+	config.SMS.URL = smsSection.Key("url").MustString("https://api.twilio.com")
+	config.SMS.AccountSID = smsSection.Key("account_sid").String()
+	config.SMS.AuthToken = smsSection.Key("auth_token").String()
+	config.SMS.Method = strings.ToUpper(smsSection.Key("method").MustString("POST"))
+	config.SMS.ContentType = smsSection.Key("content_type").MustString("application/json")
+	config.SMS.Headers = make(map[string]string)
+	config.SMS.Body = smsSection.Key("body").String()
+	for _, key := range smsSection.Keys() {
+		if name, ok := strings.CutPrefix(key.Name(), "header."); ok {
+			config.SMS.Headers[name] = key.String()
+		}
+	}
+
+	// Leer configuración de AMI
+	amiSection := cfg.Section("ami")
+	config.AMI.Host = amiSection.Key("host").MustString("127.0.0.1")
+	config.AMI.Port = amiSection.Key("port").MustInt(5038)
+	config.AMI.Username = amiSection.Key("username").String()
+	config.AMI.Secret = amiSection.Key("secret").String()
+
+	// Leer configuración de ARI
+	ariSection := cfg.Section("ari")
+	config.ARI.URL = ariSection.Key("url").MustString("http://127.0.0.1:8088")
+	config.ARI.Username = ariSection.Key("username").String()
+	config.ARI.Password = ariSection.Key("password").String()
+	config.ARI.App = ariSection.Key("app").MustString("parsewatchdog")
+
+	// Leer configuración de llamadas de voz
+	voiceSection := cfg.Section("voice")
+	config.Voice.Enabled = voiceSection.Key("enabled").MustBool(false)
+	config.Voice.Method = voiceSection.Key("method").In("callfile", []string{"callfile", "ami"})
+	config.Voice.Numbers = voiceSection.Key("numbers").Strings(",")
+	config.Voice.Channel = voiceSection.Key("channel").MustString("PJSIP/%s@provider_trunk")
+	config.Voice.CallerID = voiceSection.Key("callerid").MustString(`"ParseWatchdog" <1000>`)
+	config.Voice.Context = voiceSection.Key("context").MustString("parsewatchdog-alert")
+	config.Voice.Extension = voiceSection.Key("extension").MustString("s")
+	config.Voice.Priority = voiceSection.Key("priority").MustInt(1)
+	config.Voice.Sound = voiceSection.Key("sound").MustString("custom/parsewatchdog-alert")
+	config.Voice.MinSeverity = voiceSection.Key("min_severity").In("critical", []string{"info", "warning", "critical"})
+	config.Voice.SpoolDir = voiceSection.Key("spool_dir").MustString("/var/spool/asterisk/outgoing")
+	config.Voice.MaxRetries = voiceSection.Key("max_retries").MustInt(2)
+	config.Voice.RetryTime = voiceSection.Key("retry_time").MustInt(60)
+	config.Voice.WaitTime = voiceSection.Key("wait_time").MustInt(30)
+
+	// Leer configuración de syslog y journald
+	syslogSection := cfg.Section("syslog")
+	config.Syslog.Enabled = syslogSection.Key("enabled").MustBool(false)
+	config.Syslog.Network = syslogSection.Key("network").In("unix", []string{"unix", "udp", "tcp", "tls"})
+	config.Syslog.Address = syslogSection.Key("address").MustString("/dev/log")
+	config.Syslog.Facility = syslogSection.Key("facility").MustString("daemon")
+	config.Syslog.AppName = syslogSection.Key("app_name").MustString("parsewatchdog")
+	config.Syslog.TLSCA = syslogSection.Key("tls_ca").String()
+	config.Syslog.TLSInsecure = syslogSection.Key("tls_insecure").MustBool(false)
+	config.Syslog.Journald = syslogSection.Key("journald").In("auto", []string{"auto", "true", "false"})
+
+	// Leer configuración de Kafka
+	kafkaSection := cfg.Section("kafka")
+	config.Kafka.Enabled = kafkaSection.Key("enabled").MustBool(false)
+	config.Kafka.Brokers = kafkaSection.Key("brokers").Strings(",")
+	config.Kafka.Topic = kafkaSection.Key("topic").MustString("parsewatchdog.alerts")
+	config.Kafka.TLS = kafkaSection.Key("tls").MustBool(false)
+	config.Kafka.Username = kafkaSection.Key("username").String()
+	config.Kafka.Password = kafkaSection.Key("password").String()
+
+	// Leer configuración de NATS
+	natsSection := cfg.Section("nats")
+	config.NATS.Enabled = natsSection.Key("enabled").MustBool(false)
+	config.NATS.URL = natsSection.Key("url").MustString("nats://127.0.0.1:4222")
+	config.NATS.Subject = natsSection.Key("subject").MustString("parsewatchdog.alerts")
+	config.NATS.JetStream = natsSection.Key("jetstream").MustBool(false)
+	config.NATS.CredsFile = natsSection.Key("creds_file").String()
+	config.NATS.Username = natsSection.Key("username").String()
+	config.NATS.Password = natsSection.Key("password").String()
+
+	// Leer configuración de MQTT
+	mqttSection := cfg.Section("mqtt")
+	config.MQTT.Enabled = mqttSection.Key("enabled").MustBool(false)
+	config.MQTT.Broker = mqttSection.Key("broker").MustString("tcp://127.0.0.1:1883")
+	config.MQTT.Version = mqttSection.Key("version").In("3.1.1", []string{"3.1.1", "5"})
+	config.MQTT.ClientID = mqttSection.Key("client_id").MustString("parsewatchdog")
+	config.MQTT.Username = mqttSection.Key("username").String()
+	config.MQTT.Password = mqttSection.Key("password").String()
+	config.MQTT.TLSCA = mqttSection.Key("tls_ca").String()
+	config.MQTT.TLSInsecure = mqttSection.Key("tls_insecure").MustBool(false)
+	config.MQTT.TopicPrefix = mqttSection.Key("topic_prefix").MustString("parsewatchdog")
+	config.MQTT.QoS = mqttSection.Key("qos").RangeInt(1, 0, 2)
+	config.MQTT.Retain = mqttSection.Key("retain").MustBool(true)
+	config.MQTT.OutageThreshold = mqttSection.Key("outage_threshold").MustInt(10)
+
+	// Leer configuración de exec
+	execSection := cfg.Section("exec")
+	config.Exec.Enabled = execSection.Key("enabled").MustBool(false)
+	config.Exec.Command = execSection.Key("command").String()
+	config.Exec.WorkingDir = execSection.Key("working_dir").MustString("/")
+	config.Exec.Timeout = execSection.Key("timeout").MustInt(30)
+	if config.Exec.Timeout <= 0 {
+		return nil, fmt.Errorf("exec timeout must be at least 1 second")
+	}
+
+	// Leer configuración de remediación ([remediation] y [remediation.<nombre>])
+	remediationSection := cfg.Section("remediation")
+	config.Remediation.Enabled = remediationSection.Key("enabled").MustBool(false)
+	config.Remediation.After = remediationSection.Key("after").MustInt(60)
+	config.Remediation.Cooldown = remediationSection.Key("cooldown").MustInt(600)
+	config.Remediation.MaxPerDay = remediationSection.Key("max_per_day").MustInt(3)
+	for _, section := range remediationSection.ChildSections() {
+		action := RemediationAction{
+			Name:    strings.TrimPrefix(section.Name(), "remediation."),
+			Type:    section.Key("type").In("ami", []string{"ami", "shell"}),
+			Command: section.Key("command").String(),
+			Timeout: section.Key("timeout").MustInt(30),
+		}
+		if action.Timeout <= 0 {
+			return nil, fmt.Errorf("remediation %s: timeout must be at least 1 second", action.Name)
+		}
+		config.Remediation.Actions = append(config.Remediation.Actions, action)
+	}
+
+	// Leer configuración de webhooks ([webhook.<nombre>])
+	for _, section := range cfg.Section("webhook").ChildSections() {
+		webhook := WebhookConfig{
+			Name:        strings.TrimPrefix(section.Name(), "webhook."),
+			Enabled:     section.Key("enabled").MustBool(false),
+			URL:         section.Key("url").String(),
+			Method:      strings.ToUpper(section.Key("method").MustString("POST")),
+			ContentType: section.Key("content_type").MustString("application/json"),
+			Headers:     make(map[string]string),
+			Body:        section.Key("body").String(),
+			Resolved:    section.Key("resolved").MustBool(false),
+		}
+		for _, key := range section.Keys() {
+			if name, ok := strings.CutPrefix(key.Name(), "header."); ok {
+				webhook.Headers[name] = key.String()
+			}
+		}
+		config.Webhooks = append(config.Webhooks, webhook)
+	}
+
 	return config, nil
 }
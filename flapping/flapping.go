@@ -0,0 +1,80 @@
+package flapping
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// endpoint holds the state history of an endpoint
+type endpoint struct {
+	state       string
+	transitions []time.Time
+}
+
+// Detector counts the state transitions of every endpoint within the
+// configured window and collects the flapping endpoints for the digest
+type Detector struct {
+	config     *config.Config
+	endpoints  map[string]*endpoint
+	digest     map[string]struct{}
+	lastDigest time.Time
+}
+
+// New initializes a Detector, starting the first digest interval now
+func New(cfg *config.Config) *Detector {
+	return &Detector{
+		config:     cfg,
+		endpoints:  make(map[string]*endpoint),
+		digest:     make(map[string]struct{}),
+		lastDigest: time.Now(),
+	}
+}
+
+// Record registers the state of entity at the given time and reports whether
+// the endpoint is flapping, i.e. it changed state at least the configured
+// number of times within the window
+func (d *Detector) Record(entity, state string, at time.Time) bool {
+	e, ok := d.endpoints[entity]
+	if !ok {
+		e = &endpoint{state: state}
+		d.endpoints[entity] = e
+	}
+	if e.state != state {
+		e.state = state
+		e.transitions = append(e.transitions, at)
+	}
+
+	window := time.Duration(d.config.Flapping.Window) * time.Second
+	recent := e.transitions[:0]
+	for _, transition := range e.transitions {
+		if at.Sub(transition) < window {
+			recent = append(recent, transition)
+		}
+	}
+	e.transitions = recent
+
+	if len(recent) < d.config.Flapping.Transitions {
+		return false
+	}
+	d.digest[entity] = struct{}{}
+	return true
+}
+
+// Digest returns the endpoints that flapped since the previous digest, sorted,
+// once the digest interval has elapsed. It returns nil otherwise.
+func (d *Detector) Digest(now time.Time) []string {
+	if now.Sub(d.lastDigest) < time.Duration(d.config.Flapping.DigestInterval)*time.Second {
+		return nil
+	}
+	d.lastDigest = now
+
+	entities := make([]string, 0, len(d.digest))
+	for entity := range d.digest {
+		entities = append(entities, entity)
+	}
+	d.digest = make(map[string]struct{})
+	sort.Strings(entities)
+	return entities
+}
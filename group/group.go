@@ -0,0 +1,133 @@
+package group
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// span is a numeric range of endpoint names, e.g. 1100-1199
+type span struct {
+	from, to int
+}
+
+// Group is a compiled endpoint group with its detection threshold and window
+type Group struct {
+	Name string
+	// Threshold is the number of endpoints of the group from which an
+	// alert is sent
+	Threshold int
+	// ThresholdPercent, when not zero, raises Threshold to that percentage
+	// of the endpoints of the group in the inventory
+	ThresholdPercent float64
+	// Window is the time, from the first event, in which the endpoints are
+	// counted together. Zero groups only the events with the same timestamp.
+	Window time.Duration
+	// Severity is the severity of the alerts, raised to critical from
+	// CriticalThreshold endpoints
+	Severity          string
+	CriticalThreshold int
+
+	endpoints map[string]struct{}
+	spans     []span
+	re        *regexp.Regexp
+}
+
+// Load compiles and validates the configured groups, in configuration order
+func Load(cfg *config.Config) ([]*Group, error) {
+	var groups []*Group
+	for _, gc := range cfg.Groups {
+		g, err := compile(gc)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// compile validates a group and parses its ranges and pattern
+func compile(gc config.GroupConfig) (*Group, error) {
+	if len(gc.Endpoints) == 0 && len(gc.Ranges) == 0 && gc.Pattern == "" {
+		return nil, fmt.Errorf("group %s: endpoints, ranges or pattern is required", gc.Name)
+	}
+	if gc.Threshold < 1 {
+		return nil, fmt.Errorf("group %s: threshold must be at least 1", gc.Name)
+	}
+	if gc.ThresholdPercent < 0 || gc.ThresholdPercent > 100 {
+		return nil, fmt.Errorf("group %s: threshold_percent must be between 0 and 100", gc.Name)
+	}
+	if gc.Window < 0 {
+		return nil, fmt.Errorf("group %s: window must not be negative", gc.Name)
+	}
+
+	g := &Group{
+		Name:              gc.Name,
+		Threshold:         gc.Threshold,
+		ThresholdPercent:  gc.ThresholdPercent,
+		Window:            time.Duration(gc.Window) * time.Second,
+		Severity:          gc.Severity,
+		CriticalThreshold: gc.CriticalThreshold,
+		endpoints:         make(map[string]struct{}, len(gc.Endpoints)),
+	}
+	for _, endpoint := range gc.Endpoints {
+		g.endpoints[endpoint] = struct{}{}
+	}
+	for _, value := range gc.Ranges {
+		s, err := parseSpan(value)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", gc.Name, err)
+		}
+		g.spans = append(g.spans, s)
+	}
+	if gc.Pattern != "" {
+		re, err := regexp.Compile(gc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: invalid pattern: %w", gc.Name, err)
+		}
+		g.re = re
+	}
+	return g, nil
+}
+
+// parseSpan parses a range such as 1100-1199
+func parseSpan(value string) (span, error) {
+	fromText, toText, ok := strings.Cut(value, "-")
+	from, fromErr := strconv.Atoi(strings.TrimSpace(fromText))
+	to, toErr := strconv.Atoi(strings.TrimSpace(toText))
+	if !ok || fromErr != nil || toErr != nil || from > to {
+		return span{}, fmt.Errorf("invalid range %q, expected e.g. 1100-1199", value)
+	}
+	return span{from: from, to: to}, nil
+}
+
+// Contains reports whether entity belongs to the group. Ranges apply to the
+// numeric endpoint names only.
+func (g *Group) Contains(entity string) bool {
+	if _, ok := g.endpoints[entity]; ok {
+		return true
+	}
+	if number, err := strconv.Atoi(entity); err == nil {
+		for _, s := range g.spans {
+			if number >= s.from && number <= s.to {
+				return true
+			}
+		}
+	}
+	return g.re != nil && g.re.MatchString(entity)
+}
+
+// Of returns the groups entity belongs to
+func Of(groups []*Group, entity string) []*Group {
+	var matched []*Group
+	for _, g := range groups {
+		if g.Contains(entity) {
+			matched = append(matched, g)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,124 @@
+package inventory
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/ami"
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// Inventory keeps the known endpoints, with their tenant, from the observed
+// events, the configured list and the AMI PJSIPShowEndpoints action
+type Inventory struct {
+	config *config.Config
+	tenant func(string) string
+
+	mu         sync.Mutex
+	observed   map[string]string
+	configured map[string]string
+	queried    map[string]string
+}
+
+// New initializes an Inventory with the configured endpoints. tenant returns
+// the tenant of an endpoint name.
+func New(cfg *config.Config, tenant func(string) string) *Inventory {
+	inv := &Inventory{
+		config:     cfg,
+		tenant:     tenant,
+		observed:   make(map[string]string),
+		configured: make(map[string]string),
+		queried:    make(map[string]string),
+	}
+	if inv.uses("config") {
+		for _, entity := range cfg.Inventory.Endpoints {
+			if inv.endpoint(entity) {
+				inv.configured[entity] = tenant(entity)
+			}
+		}
+	}
+	return inv
+}
+
+// Queries reports whether the endpoints are queried through the AMI
+func (inv *Inventory) Queries() bool {
+	return inv.uses("ami")
+}
+
+// Refresh replaces the queried endpoints with the PJSIPShowEndpoints list and
+// returns their number
+func (inv *Inventory) Refresh() (int, error) {
+	client, err := ami.Dial(inv.config.AMI.Address(), inv.config.AMI.Username, inv.config.AMI.Secret, 10*time.Second, "off")
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	list, err := client.List("PJSIPShowEndpoints", nil)
+	if err != nil {
+		return 0, err
+	}
+	queried := make(map[string]string, len(list))
+	for _, msg := range list {
+		entity := msg["ObjectName"]
+		if msg["Event"] == "EndpointList" && inv.endpoint(entity) {
+			queried[entity] = inv.tenant(entity)
+		}
+	}
+
+	inv.mu.Lock()
+	inv.queried = queried
+	inv.mu.Unlock()
+	return len(queried), nil
+}
+
+// Observe adds an endpoint seen in an event, when observed endpoints are
+// part of the inventory. tenant may be empty to use the tenant pattern.
+func (inv *Inventory) Observe(entity, tenant string) {
+	if !inv.uses("observed") || !inv.endpoint(entity) {
+		return
+	}
+	if tenant == "" {
+		tenant = inv.tenant(entity)
+	}
+	inv.mu.Lock()
+	inv.observed[entity] = tenant
+	inv.mu.Unlock()
+}
+
+// Size returns the number of known endpoints of tenant, or of all of them
+// when tenant is empty
+func (inv *Inventory) Size(tenant string) int {
+	return inv.Count(func(_, entityTenant string) bool {
+		return tenant == "" || entityTenant == tenant
+	})
+}
+
+// Count returns the number of known endpoints for which match, called with
+// the endpoint name and tenant, returns true
+func (inv *Inventory) Count(match func(entity, tenant string) bool) int {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	for _, endpoints := range []map[string]string{inv.observed, inv.configured, inv.queried} {
+		for entity, tenant := range endpoints {
+			if match(entity, tenant) {
+				seen[entity] = struct{}{}
+			}
+		}
+	}
+	return len(seen)
+}
+
+// uses reports whether source is one of the inventory sources
+func (inv *Inventory) uses(source string) bool {
+	return slices.Contains(inv.config.Inventory.Sources, source)
+}
+
+// endpoint reports whether entity is a phone endpoint, trunks are not part of
+// the inventory
+func (inv *Inventory) endpoint(entity string) bool {
+	return entity != "" && !slices.Contains(inv.config.Trunks.Names, entity)
+}
@@ -0,0 +1,88 @@
+package latency
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// sample is a qualify round-trip time measured at a given time
+type sample struct {
+	at  time.Time
+	rtt time.Duration
+}
+
+// Monitor keeps the recent qualify RTTs of every endpoint and reports the
+// endpoints whose RTT percentile is above the threshold
+type Monitor struct {
+	config  *config.Config
+	samples map[string][]sample
+}
+
+// New initializes a Monitor
+func New(cfg *config.Config) *Monitor {
+	return &Monitor{config: cfg, samples: make(map[string][]sample)}
+}
+
+// Add records a qualify RTT of entity, keeping at most the configured number
+// of samples per endpoint
+func (m *Monitor) Add(entity string, rtt time.Duration, at time.Time) {
+	samples := append(m.samples[entity], sample{at: at, rtt: rtt})
+	if excess := len(samples) - m.config.Latency.Samples; excess > 0 {
+		samples = samples[excess:]
+	}
+	m.samples[entity] = samples
+}
+
+// Remove forgets the samples of an endpoint that became unreachable
+func (m *Monitor) Remove(entity string) {
+	delete(m.samples, entity)
+}
+
+// Check drops the samples older than the window and returns the endpoints
+// whose RTT percentile is above the threshold, sorted, and the number of
+// endpoints with recent samples
+func (m *Monitor) Check(now time.Time) ([]string, int) {
+	window := time.Duration(m.config.Latency.Window) * time.Second
+	threshold := time.Duration(m.config.Latency.Threshold) * time.Millisecond
+
+	var degraded []string
+	for entity, samples := range m.samples {
+		recent := samples[:0]
+		for _, s := range samples {
+			if now.Sub(s.at) < window {
+				recent = append(recent, s)
+			}
+		}
+		if len(recent) == 0 {
+			delete(m.samples, entity)
+			continue
+		}
+		m.samples[entity] = recent
+
+		if percentile(recent, m.config.Latency.Percentile) > threshold {
+			degraded = append(degraded, entity)
+		}
+	}
+	sort.Strings(degraded)
+	return degraded, len(m.samples)
+}
+
+// percentile returns the p-th percentile of the samples RTT, using the
+// nearest-rank method
+func percentile(samples []sample, p int) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	rtts := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		rtts[i] = s.rtt
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(rtts))))
+	rank = min(max(rank, 1), len(rtts))
+	return rtts[rank-1]
+}
@@ -0,0 +1,296 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Alert severities, from lowest to highest
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert kinds
+const (
+	KindMassDisconnection = "mass_disconnection"
+	KindTrunkDown         = "trunk_down"
+	KindBruteForce        = "brute_force"
+	KindLatency           = "latency"
+	KindFlapping          = "flapping"
+	// KindRemediation is the informational follow-up of an incident with
+	// the outcome of its remediation, never sent to the paging channels
+	KindRemediation = "remediation"
+)
+
+// Trunk down reasons
+const (
+	ReasonUnreachable  = "unreachable"
+	ReasonRegistration = "registration"
+)
+
+// Alert holds the data of a detected mass disconnection, trunk down,
+// brute-force attempt, latency degradation, flapping endpoints digest or
+// remediation follow-up. ID
+// identifies the incident and is kept when the same alert is sent again as
+// resolved.
+type Alert struct {
+	ID         string
+	Kind       string
+	Host       string
+	Timestamp  string
+	Extensions []string
+	Severity   string
+	Resolved   bool
+	// Tenant is set when the extensions belong to a tenant of a
+	// multi-tenant PBX
+	Tenant string
+	// Group is the endpoint group of a mass disconnection, whose channels
+	// receive the alert
+	Group string
+	// Reason and Detail explain why a trunk is down, e.g. registration and
+	// the SIP response code. For brute-force attempts Detail holds the
+	// failure logged by Asterisk.
+	Reason string
+	Detail string
+	// Attempts is the number of failed authentications of a brute-force
+	// attempt, whose source IP is the only entry of Extensions
+	Attempts int
+	// Observed is the size of the endpoint inventory in a mass disconnection
+	// alert, or the number of endpoints with recent qualify RTTs in a
+	// latency alert, whose Extensions are the degraded ones and Detail the
+	// condition, e.g. "p95 RTT > 300ms"
+	Observed int
+	// AcknowledgedBy is set when someone acknowledges the incident, e.g.
+	// by pressing a key during a voice call
+	AcknowledgedBy string
+	// Remediation holds the outcome of the remediation actions, sent in a
+	// remediation follow-up of the same incident
+	Remediation []ActionResult
+
+	// route holds the channels the alert was sent through, used again when
+	// it is resolved
+	route channels
+}
+
+// ActionResult is the outcome of a remediation action
+type ActionResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Output  string `json:"output,omitempty"`
+}
+
+// Level returns the alert severity, defaulting to warning when unset
+func (a *Alert) Level() string {
+	if a.Severity == "" {
+		return SeverityWarning
+	}
+	return a.Severity
+}
+
+// AtLeast reports whether the alert severity is equal or higher than severity
+func (a *Alert) AtLeast(severity string) bool {
+	return severityRank(a.Level()) >= severityRank(severity)
+}
+
+// severityRank orders severities from lowest to highest
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityInfo:
+		return 0
+	case SeverityCritical:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Color returns the RGB colour associated with the alert severity
+func (a *Alert) Color() int {
+	switch a.Level() {
+	case SeverityInfo:
+		return 0x439FE0
+	case SeverityCritical:
+		return 0xE01E5A
+	default:
+		return 0xECB22E
+	}
+}
+
+// Type returns the alert kind, defaulting to mass disconnection when unset
+func (a *Alert) Type() string {
+	if a.Kind == "" {
+		return KindMassDisconnection
+	}
+	return a.Kind
+}
+
+// pages reports whether the alert is sent to the paging channels: PagerDuty,
+// Opsgenie, SMS and voice. The flapping digests and the remediation
+// follow-ups are informational and never page, as nothing would close them.
+func (a *Alert) pages() bool {
+	switch a.Type() {
+	case KindFlapping, KindRemediation:
+		return false
+	}
+	return true
+}
+
+// TotalExtensions returns the number of disconnected extensions
+func (a *Alert) TotalExtensions() int {
+	return len(a.Extensions)
+}
+
+// Title returns the localized alert title
+func (a *Alert) Title(c Catalog) string {
+	switch a.Type() {
+	case KindTrunkDown:
+		return c.T("trunk.title")
+	case KindBruteForce:
+		return c.T("security.title")
+	case KindLatency:
+		return c.T("latency.title")
+	case KindFlapping:
+		return c.T("flapping.title")
+	case KindRemediation:
+		return c.T("remediation.title")
+	}
+	return c.T("alert.title")
+}
+
+// Subject builds the localized alert subject
+func (a *Alert) Subject(c Catalog) string {
+	switch a.Type() {
+	case KindTrunkDown:
+		return fmt.Sprintf(c.T("trunk.subject"), strings.Join(a.Extensions, ", "), a.Timestamp)
+	case KindBruteForce:
+		return fmt.Sprintf(c.T("security.subject"), a.Attempts, strings.Join(a.Extensions, ", "), a.Timestamp)
+	case KindLatency:
+		return fmt.Sprintf(c.T("latency.subject"), a.TotalExtensions(), a.Observed, a.Detail, a.Timestamp)
+	case KindFlapping:
+		return fmt.Sprintf(c.T("flapping.subject"), a.TotalExtensions(), a.Timestamp)
+	case KindRemediation:
+		return fmt.Sprintf(c.T("followup.subject"), a.ID, a.Timestamp)
+	}
+	return fmt.Sprintf(c.T("alert.subject"), a.TotalExtensions(), a.Timestamp)
+}
+
+// Message builds the localized plain text alert body
+func (a *Alert) Message(c Catalog) string {
+	message := fmt.Sprintf(c.T("alert.message"), a.Timestamp, a.TotalExtensions(), strings.Join(a.Extensions, ", "))
+	switch a.Type() {
+	case KindTrunkDown:
+		message = fmt.Sprintf(c.T("trunk.message"), strings.Join(a.Extensions, ", "), a.Timestamp, a.ReasonText(c))
+	case KindBruteForce:
+		message = fmt.Sprintf(c.T("security.message"), a.Timestamp, a.Attempts, strings.Join(a.Extensions, ", "), a.ReasonText(c))
+	case KindLatency:
+		message = fmt.Sprintf(c.T("latency.message"), a.Timestamp, a.TotalExtensions(), a.Observed, a.Detail, strings.Join(a.Extensions, ", "))
+	case KindFlapping:
+		message = fmt.Sprintf(c.T("flapping.message"), a.Timestamp, a.TotalExtensions(), a.Detail, strings.Join(a.Extensions, ", "))
+	case KindRemediation:
+		message = fmt.Sprintf(c.T("followup.message"), a.ID, a.Timestamp, strings.Join(a.Extensions, ", "))
+	}
+	if a.Tenant != "" {
+		message += "\n" + c.T("alert.tenant") + ": " + a.Tenant
+	}
+	if a.Group != "" {
+		message += "\n" + c.T("alert.group") + ": " + a.Group
+	}
+	if len(a.Remediation) > 0 {
+		message += "\n" + c.T("remediation.title") + ":\n" + a.RemediationSummary(c)
+	}
+	return message
+}
+
+// ResolvedText returns the localized message of a resolved alert
+func (a *Alert) ResolvedText(c Catalog) string {
+	if a.Type() == KindBruteForce {
+		return fmt.Sprintf(c.T("security.resolved"), a.ID, strings.Join(a.Extensions, ", "))
+	}
+	return fmt.Sprintf(c.T("alert.resolved"), a.ID)
+}
+
+// ReasonText returns the localized reason of a trunk down alert, or the
+// detail of the other alerts, e.g. the failure logged by Asterisk for a
+// brute-force attempt
+func (a *Alert) ReasonText(c Catalog) string {
+	if a.Type() != KindTrunkDown {
+		return a.Detail
+	}
+	reason := c.T("trunk." + a.Reason)
+	if a.Detail != "" {
+		reason += " (" + a.Detail + ")"
+	}
+	return reason
+}
+
+// RemediationSummary lists the remediation actions and their outcome, one
+// per line
+func (a *Alert) RemediationSummary(c Catalog) string {
+	lines := make([]string, 0, len(a.Remediation))
+	for _, result := range a.Remediation {
+		if result.Success {
+			lines = append(lines, fmt.Sprintf("%s: %s", result.Name, c.T("remediation.ok")))
+			continue
+		}
+		// The last output line holds the error
+		output := result.Output[strings.LastIndex(result.Output, "\n")+1:]
+		lines = append(lines, fmt.Sprintf("%s: %s (%s)", result.Name, c.T("remediation.failed"), output))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// alertEvent is the structured JSON representation of an alert
+type alertEvent struct {
+	Event           string         `json:"event"`
+	ID              string         `json:"id"`
+	Kind            string         `json:"kind"`
+	Host            string         `json:"host"`
+	Timestamp       string         `json:"timestamp"`
+	Tenant          string         `json:"tenant,omitempty"`
+	Group           string         `json:"group,omitempty"`
+	Severity        string         `json:"severity"`
+	TotalExtensions int            `json:"total_extensions"`
+	Extensions      []string       `json:"extensions"`
+	Resolved        bool           `json:"resolved"`
+	Reason          string         `json:"reason,omitempty"`
+	Detail          string         `json:"detail,omitempty"`
+	Attempts        int            `json:"attempts,omitempty"`
+	Observed        int            `json:"observed,omitempty"`
+	AcknowledgedBy  string         `json:"acknowledged_by,omitempty"`
+	Remediation     []ActionResult `json:"remediation,omitempty"`
+	Subject         string         `json:"subject"`
+	Message         string         `json:"message"`
+}
+
+// JSON encodes the alert as structured JSON for event buses
+func (a *Alert) JSON(c Catalog) ([]byte, error) {
+	event := alertEvent{
+		Event:           "alert",
+		ID:              a.ID,
+		Kind:            a.Type(),
+		Host:            a.Host,
+		Timestamp:       a.Timestamp,
+		Tenant:          a.Tenant,
+		Group:           a.Group,
+		Severity:        a.Level(),
+		TotalExtensions: a.TotalExtensions(),
+		Extensions:      a.Extensions,
+		Resolved:        a.Resolved,
+		Reason:          a.Reason,
+		Detail:          a.Detail,
+		Attempts:        a.Attempts,
+		Observed:        a.Observed,
+		AcknowledgedBy:  a.AcknowledgedBy,
+		Remediation:     a.Remediation,
+		Subject:         a.Subject(c),
+		Message:         a.Message(c),
+	}
+	if a.Resolved {
+		event.Event = "resolved"
+		event.Message = a.ResolvedText(c)
+	}
+	return json.Marshal(event)
+}
@@ -10,15 +10,16 @@ import (
 )
 
 type APINotifier struct {
-	config *config.Config
+	config  *config.Config
+	catalog Catalog
 }
 
 func NewAPINotifier(cfg *config.Config) *APINotifier {
-	return &APINotifier{config: cfg}
+	return &APINotifier{config: cfg, catalog: NewCatalog(cfg)}
 }
 
-func (n *APINotifier) Send(subject, message string) error {
-	payload := map[string]string{"subject": subject, "message": message}
+func (n *APINotifier) Send(alert *Alert) error {
+	payload := map[string]string{"subject": alert.Subject(n.catalog), "message": alert.Message(n.catalog)}
 	jsonData, _ := json.Marshal(payload)
 
 	req, err := http.NewRequest("POST", n.config.API.Endpoint, bytes.NewBuffer(jsonData))
@@ -28,9 +29,7 @@ func (n *APINotifier) Send(subject, message string) error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("API-Key", n.config.API.APIKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil || resp.StatusCode != http.StatusOK {
+	if err := doRequest(req); err != nil {
 		return fmt.Errorf("failed to send API notification: %w", err)
 	}
 	return nil
@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// discordFieldLimit is the maximum length of an embed field value
+const discordFieldLimit = 1024
+
+// discordDescriptionLimit is the maximum length of an embed description
+const discordDescriptionLimit = 4096
+
+// DiscordNotifier manages Discord notifications
+type DiscordNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewDiscordNotifier initializes a DiscordNotifier
+func NewDiscordNotifier(cfg *config.Config) *DiscordNotifier {
+	return &DiscordNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send posts an embed coloured by severity to the Discord webhook
+func (n *DiscordNotifier) Send(alert *Alert) error {
+	embed := map[string]interface{}{
+		"title": "🚨 " + alert.Title(n.catalog),
+		"color": alert.Color(),
+	}
+
+	// The other kinds show their own message, e.g. with the attempts of a
+	// brute-force alert
+	if alert.Type() != KindMassDisconnection {
+		embed["description"] = truncate(alert.Message(n.catalog), discordDescriptionLimit)
+	} else {
+		extensions := truncate(strings.Join(alert.Extensions, ", "), discordFieldLimit)
+
+		fields := []map[string]interface{}{
+			{"name": "📅 " + n.catalog.T("alert.time"), "value": alert.Timestamp, "inline": true},
+			{"name": "🔢 " + n.catalog.T("alert.total"), "value": strconv.Itoa(alert.TotalExtensions()), "inline": true},
+			{"name": "📋 " + n.catalog.T("alert.list"), "value": extensions},
+		}
+		if len(alert.Remediation) > 0 {
+			remediation := truncate(alert.RemediationSummary(n.catalog), discordFieldLimit)
+			fields = append(fields, map[string]interface{}{"name": "🛠 " + n.catalog.T("remediation.title"), "value": remediation})
+		}
+		embed["fields"] = fields
+	}
+
+	data := map[string]interface{}{
+		"username": n.config.Discord.Username,
+		"embeds":   []map[string]interface{}{embed},
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode Discord message: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", n.config.Discord.WebhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending message to Discord: %w", err)
+	}
+	return nil
+}
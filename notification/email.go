@@ -3,46 +3,59 @@ package notification
 import (
 	"bytes"
 	"fmt"
+	"mime"
 	"net/smtp"
 
 	"github.com/lordbasex/parsewatchdog/config"
 
+	"html/template"
 	"strings"
-	"text/template"
 )
 
 // EmailNotifier manages email notifications
 type EmailNotifier struct {
-	config *config.Config
+	config  *config.Config
+	catalog Catalog
 }
 
 // NewEmailNotifier initializes an EmailNotifier
 func NewEmailNotifier(cfg *config.Config) *EmailNotifier {
-	return &EmailNotifier{config: cfg}
+	return &EmailNotifier{config: cfg, catalog: NewCatalog(cfg)}
 }
 
-// HTML template for the email content
+// HTML template for the email content, every field is escaped
 const emailTemplate = `
 <!DOCTYPE html>
-<html lang="en">
+<html lang="{{.Lang}}">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Mass Disconnection Alert</title>
+    <title>{{.Title}}</title>
 </head>
 <body style="font-family: Arial, sans-serif; color: #333;">
-    <h2>Mass Disconnection Alert</h2>
-    <p><strong>Timestamp:</strong> {{.Timestamp}}</p>
-    <p><strong>Total Extensions Disconnected:</strong> {{.TotalExtensions}}</p>
-    <p><strong>Extensions:</strong> {{.Extensions}}</p>
+    <h2>{{.Title}}</h2>
+    <p><strong>{{t "alert.timestamp"}}:</strong> {{.Timestamp}}</p>
+    <p><strong>{{t "alert.total"}}:</strong> {{.TotalExtensions}}</p>
+    <p><strong>{{t "alert.extensions"}}:</strong> {{.Extensions}}</p>
+    {{- if .Reason}}
+    <p><strong>{{t "alert.reason"}}:</strong> {{.Reason}}</p>
+    {{- end}}
+    {{- if .Remediation}}
+    <p><strong>{{t "remediation.title"}}:</strong></p>
+    <ul>
+    {{- range .Remediation}}
+        <li>{{.}}</li>
+    {{- end}}
+    </ul>
+    {{- end}}
     <hr>
-    <p>Please review this issue as soon as possible.</p>
+    <p>{{t "alert.review"}}</p>
 </body>
 </html>
 `
 
-// Send sends an email using the HTML template and provided arguments
-func (n *EmailNotifier) Send(subject, message string) error {
+// Send sends an email using the HTML template and the alert data
+func (n *EmailNotifier) Send(alert *Alert) error {
 	from := n.config.SMTP.User
 	pass := n.config.SMTP.Pass
 	host := n.config.SMTP.Host
@@ -53,27 +66,40 @@ func (n *EmailNotifier) Send(subject, message string) error {
 	address := fmt.Sprintf("%s:%d", host, port)
 	auth := smtp.PlainAuth("", from, pass, host)
 
-	// Parse `message` to extract timestamp, totalExtensions, and extensions
-	parsedData, err := parseMessage(message)
-	if err != nil {
-		return fmt.Errorf("error parsing message: %v", err)
-	}
-
-	// Parse and execute the email template with extracted data
-	tmpl, err := template.New("emailTemplate").Parse(emailTemplate)
+	// Parse the email template, exposing the catalog as the "t" function
+	tmpl, err := template.New("emailTemplate").Funcs(template.FuncMap{"t": n.catalog.T}).Parse(emailTemplate)
 	if err != nil {
 		return fmt.Errorf("error parsing template: %v", err)
 	}
 
 	var body bytes.Buffer
 	body.WriteString("To: " + strings.Join(to, ",") + "\r\n")
-	body.WriteString("Subject: " + subject + "\r\n")
+	// Non-ASCII subjects, e.g. translated ones, are encoded as RFC 2047 words
+	body.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", alert.Subject(n.catalog)) + "\r\n")
 	body.WriteString("MIME-version: 1.0;\r\n")
 	body.WriteString("Content-Type: text/html; charset=\"UTF-8\";\r\n")
 	body.WriteString("\r\n")
 
-	// Execute template with the parsed data
-	err = tmpl.Execute(&body, parsedData)
+	var reason string
+	if alert.Type() != KindMassDisconnection {
+		reason = alert.ReasonText(n.catalog)
+	}
+
+	var remediation []string
+	if len(alert.Remediation) > 0 {
+		remediation = strings.Split(alert.RemediationSummary(n.catalog), "\n")
+	}
+
+	// Execute template with the alert data
+	err = tmpl.Execute(&body, map[string]interface{}{
+		"Lang":            n.config.General.Language,
+		"Title":           alert.Title(n.catalog),
+		"Reason":          reason,
+		"Timestamp":       alert.Timestamp,
+		"TotalExtensions": alert.TotalExtensions(),
+		"Extensions":      strings.Join(alert.Extensions, ", "),
+		"Remediation":     remediation,
+	})
 	if err != nil {
 		return fmt.Errorf("error executing template: %v", err)
 	}
@@ -86,24 +112,3 @@ func (n *EmailNotifier) Send(subject, message string) error {
 
 	return nil
 }
-
-// parseMessage extracts timestamp, totalExtensions, and extensions from the message string
-func parseMessage(message string) (map[string]interface{}, error) {
-	lines := strings.Split(message, "\n")
-	if len(lines) < 3 {
-		return nil, fmt.Errorf("message format is invalid")
-	}
-
-	// Extract fields from lines assuming specific format
-	timestamp := strings.TrimSpace(strings.TrimPrefix(lines[0], "Mass disconnection detected at"))
-	totalExtensionsStr := strings.TrimSpace(strings.TrimPrefix(lines[1], "Total: "))
-	totalExtensions := strings.Split(totalExtensionsStr, " ")[0] // extract number only
-	extensions := strings.TrimSpace(strings.TrimPrefix(lines[2], "Extensions:"))
-
-	// Prepare the data map for the template
-	return map[string]interface{}{
-		"Timestamp":       timestamp,
-		"TotalExtensions": totalExtensions,
-		"Extensions":      extensions,
-	}, nil
-}
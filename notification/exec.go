@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// ExecNotifier runs a local command with the alert as JSON on stdin and as
+// PWD_* environment variables
+type ExecNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewExecNotifier initializes an ExecNotifier
+func NewExecNotifier(cfg *config.Config) *ExecNotifier {
+	return &ExecNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send runs the configured command through /bin/sh and waits for it to
+// finish or for the timeout to expire
+func (n *ExecNotifier) Send(alert *Alert) error {
+	payload, err := alert.JSON(n.catalog)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(n.config.Exec.Timeout)*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", n.config.Exec.Command)
+	cmd.Dir = n.config.Exec.WorkingDir
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Run the command in its own process group so the timeout also kills
+	// any child started by the script
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"PWD_INCIDENT_ID="+alert.ID,
+		"PWD_HOST="+alert.Host,
+		"PWD_TIMESTAMP="+alert.Timestamp,
+		"PWD_TENANT="+alert.Tenant,
+		"PWD_GROUP="+alert.Group,
+		"PWD_SEVERITY="+alert.Level(),
+		"PWD_EXTENSION_COUNT="+strconv.Itoa(alert.TotalExtensions()),
+		"PWD_EXTENSIONS="+strings.Join(alert.Extensions, ","),
+		"PWD_RESOLVED="+strconv.FormatBool(alert.Resolved),
+		"PWD_SUBJECT="+alert.Subject(n.catalog),
+	)
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %ds", n.config.Exec.Timeout)
+	}
+
+	// The command output is only logged with full debugging, or along with
+	// the error when the command fails
+	if err != nil || n.config.Debug.DebugLevel >= 2 {
+		if out := strings.TrimSpace(stdout.String()); out != "" {
+			log.Printf("Exec notifier stdout: %s", out)
+		}
+		if out := strings.TrimSpace(stderr.String()); out != "" {
+			log.Printf("Exec notifier stderr: %s", out)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("command %q failed: %w", n.config.Exec.Command, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package notification
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpClient is shared by every HTTP based notifier
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// doRequest sends req with the shared client and fails on any non-2xx status
+func doRequest(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// truncate cuts s to limit characters, ending with "..." when it is cut, so
+// multi-byte characters are never split
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-3]) + "..."
+}
@@ -0,0 +1,154 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lordbasex/parsewatchdog/config"
+	"gopkg.in/ini.v1"
+)
+
+// defaultLanguage is used as fallback for any missing translation
+const defaultLanguage = "en"
+
+// Catalog maps message keys to translated text
+type Catalog map[string]string
+
+// builtinCatalogs contains the translations shipped with the binary
+var builtinCatalogs = map[string]Catalog{
+	"en": {
+		"alert.title":        "Mass Disconnection Alert",
+		"alert.subject":      "Mass Disconnection Alert: %d extensions disconnected at %s",
+		"alert.message":      "Mass disconnection detected at %s:\nTotal: %d extensions disconnected.\nExtensions: %s",
+		"alert.time":         "Time",
+		"alert.timestamp":    "Timestamp",
+		"alert.total":        "Total Extensions Disconnected",
+		"alert.extensions":   "Extensions",
+		"alert.list":         "Extensions List",
+		"alert.tenant":       "Tenant",
+		"alert.group":        "Group",
+		"alert.reason":       "Reason",
+		"alert.review":       "Please review this issue as soon as possible.",
+		"alert.resolved":     "Incident %s resolved: all extensions are reachable again",
+		"sms.text":           "ALERT: %d extensions unreachable on %s at %s.",
+		"remediation.title":  "Remediation",
+		"remediation.ok":     "OK",
+		"remediation.failed": "FAILED",
+		"followup.subject":   "Remediation of incident %s opened at %s",
+		"followup.message":   "Remediation run for incident %s opened at %s.\nExtensions: %s",
+		"trunk.title":        "Trunk Down Alert",
+		"trunk.subject":      "Trunk Down Alert: %s down at %s",
+		"trunk.message":      "Trunk %s went down at %s.\nReason: %s",
+		"trunk.unreachable":  "endpoint unreachable",
+		"trunk.registration": "outbound registration failed",
+		"sms.trunk":          "ALERT: trunk %s down on %s at %s.",
+		"security.title":     "Security Alert",
+		"security.subject":   "Security Alert: %d failed authentication attempts from %s at %s",
+		"security.message":   "Brute-force attempt detected at %s:\n%d failed authentication attempts from %s.\nReason: %s",
+		"sms.security":       "ALERT: %d failed SIP authentications from %s on %s at %s.",
+		"security.resolved":  "Incident %s resolved: the block of %s expired",
+		"latency.title":      "Latency Degradation Alert",
+		"latency.subject":    "Latency Degradation Alert: %d of %d endpoints with %s at %s",
+		"latency.message":    "Qualify latency degradation detected at %s:\n%d of %d endpoints with %s.\nExtensions: %s",
+		"sms.latency":        "ALERT: %d of %d endpoints with %s on %s at %s.",
+		"flapping.title":     "Flapping Endpoints Digest",
+		"flapping.subject":   "Flapping Endpoints Digest: %d endpoints at %s",
+		"flapping.message":   "Flapping endpoints digest at %s:\n%d endpoints with %s, not counted in mass disconnections.\nExtensions: %s",
+	},
+	"es": {
+		"alert.title":        "Alerta de Desconexión Masiva",
+		"alert.subject":      "Alerta de Desconexión Masiva: %d extensiones desconectadas a las %s",
+		"alert.message":      "Desconexión masiva detectada a las %s:\nTotal: %d extensiones desconectadas.\nExtensiones: %s",
+		"alert.time":         "Hora",
+		"alert.timestamp":    "Fecha y hora",
+		"alert.total":        "Total de Extensiones Desconectadas",
+		"alert.extensions":   "Extensiones",
+		"alert.list":         "Lista de Extensiones",
+		"alert.tenant":       "Inquilino",
+		"alert.group":        "Grupo",
+		"alert.reason":       "Motivo",
+		"alert.review":       "Por favor, revise este problema lo antes posible.",
+		"alert.resolved":     "Incidente %s resuelto: todas las extensiones vuelven a estar alcanzables",
+		"sms.text":           "ALERTA: %d extensiones inalcanzables en %s a las %s.",
+		"remediation.title":  "Remediación",
+		"remediation.ok":     "OK",
+		"remediation.failed": "FALLÓ",
+		"followup.subject":   "Remediación del incidente %s abierto a las %s",
+		"followup.message":   "Remediación ejecutada para el incidente %s abierto a las %s.\nExtensiones: %s",
+		"trunk.title":        "Alerta de Troncal Caída",
+		"trunk.subject":      "Alerta de Troncal Caída: %s caída a las %s",
+		"trunk.message":      "La troncal %s se cayó a las %s.\nMotivo: %s",
+		"trunk.unreachable":  "endpoint inalcanzable",
+		"trunk.registration": "falló el registro saliente",
+		"sms.trunk":          "ALERTA: troncal %s caída en %s a las %s.",
+		"security.title":     "Alerta de Seguridad",
+		"security.subject":   "Alerta de Seguridad: %d intentos de autenticación fallidos desde %s a las %s",
+		"security.message":   "Intento de fuerza bruta detectado a las %s:\n%d intentos de autenticación fallidos desde %s.\nMotivo: %s",
+		"sms.security":       "ALERTA: %d autenticaciones SIP fallidas desde %s en %s a las %s.",
+		"security.resolved":  "Incidente %s resuelto: expiró el bloqueo de %s",
+		"latency.title":      "Alerta de Degradación de Latencia",
+		"latency.subject":    "Alerta de Degradación de Latencia: %d de %d extensiones con %s a las %s",
+		"latency.message":    "Degradación de la latencia de qualify detectada a las %s:\n%d de %d extensiones con %s.\nExtensiones: %s",
+		"sms.latency":        "ALERTA: %d de %d extensiones con %s en %s a las %s.",
+		"flapping.title":     "Resumen de Extensiones Inestables",
+		"flapping.subject":   "Resumen de Extensiones Inestables: %d extensiones a las %s",
+		"flapping.message":   "Resumen de extensiones inestables a las %s:\n%d extensiones con %s, no contadas en las desconexiones masivas.\nExtensiones: %s",
+	},
+}
+
+// NewCatalog builds the catalog for the configured language. Translations
+// are resolved from <translations_dir>/<language>.ini first, then from the
+// built-in catalog of that language and finally from English.
+func NewCatalog(cfg *config.Config) Catalog {
+	lang := strings.ToLower(strings.TrimSpace(cfg.General.Language))
+	if lang == "" {
+		lang = defaultLanguage
+	}
+
+	catalog := Catalog{}
+	for key, value := range builtinCatalogs[defaultLanguage] {
+		catalog[key] = value
+	}
+	for key, value := range builtinCatalogs[lang] {
+		catalog[key] = value
+	}
+
+	if cfg.General.TranslationsDir == "" {
+		return catalog
+	}
+	path := filepath.Join(cfg.General.TranslationsDir, lang+".ini")
+	if _, err := os.Stat(path); err != nil {
+		if _, builtin := builtinCatalogs[lang]; !builtin {
+			log.Printf("No translations found for language %q, using %q", lang, defaultLanguage)
+		}
+		return catalog
+	}
+	if err := catalog.loadFile(path); err != nil {
+		log.Printf("Error loading translation file %s: %v", path, err)
+	}
+	return catalog
+}
+
+// loadFile overrides the catalog entries with the keys found in an INI file.
+// Escaped "\n" sequences are turned into line breaks.
+func (c Catalog) loadFile(path string) error {
+	file, err := ini.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse translations: %w", err)
+	}
+	for _, key := range file.Section(ini.DefaultSection).Keys() {
+		c[key.Name()] = strings.ReplaceAll(key.String(), `\n`, "\n")
+	}
+	return nil
+}
+
+// T returns the translation for key, or the key itself when it is unknown
+func (c Catalog) T(key string) string {
+	if value, ok := c[key]; ok {
+		return value
+	}
+	return key
+}
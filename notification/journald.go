@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// journalSocket is the native protocol socket of systemd-journald
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournaldNotifier writes alerts to the systemd journal with PWD_* fields
+type JournaldNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewJournaldNotifier initializes a JournaldNotifier
+func NewJournaldNotifier(cfg *config.Config) *JournaldNotifier {
+	return &JournaldNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// JournaldAvailable reports whether the process runs under systemd with a
+// reachable journal
+func JournaldAvailable() bool {
+	if os.Getenv("INVOCATION_ID") == "" && os.Getenv("JOURNAL_STREAM") == "" {
+		return false
+	}
+	_, err := os.Stat(journalSocket)
+	return err == nil
+}
+
+// Send writes one journal entry for the alert
+func (n *JournaldNotifier) Send(alert *Alert) error {
+	message := alert.Subject(n.catalog)
+	if alert.Resolved {
+		message = alert.ResolvedText(n.catalog)
+	}
+
+	fields := [][2]string{
+		{"MESSAGE", message},
+		{"PRIORITY", strconv.Itoa(syslogSeverity(alert))},
+		{"SYSLOG_IDENTIFIER", n.config.Syslog.AppName},
+		{"PWD_INCIDENT_ID", alert.ID},
+		{"PWD_HOST", alert.Host},
+		{"PWD_TIMESTAMP", alert.Timestamp},
+		{"PWD_TENANT", alert.Tenant},
+		{"PWD_GROUP", alert.Group},
+		{"PWD_SEVERITY", alert.Level()},
+		{"PWD_EXTENSION_COUNT", strconv.Itoa(alert.TotalExtensions())},
+		{"PWD_EXTENSIONS", strings.Join(alert.Extensions, ",")},
+		{"PWD_RESOLVED", strconv.FormatBool(alert.Resolved)},
+	}
+
+	var entry bytes.Buffer
+	for _, field := range fields {
+		writeJournalField(&entry, field[0], field[1])
+	}
+
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(entry.Bytes()); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return nil
+}
+
+// writeJournalField encodes a field in the journal native protocol. Values
+// containing new lines use the binary form: NAME\n<uint64 LE size>VALUE\n
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", name, value)
+		return
+	}
+	b.WriteString(name)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
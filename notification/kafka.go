@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// kafkaWriter is the part of kafka.Writer used by the notifier
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaNotifier publishes the structured alert JSON to a Kafka topic
+type KafkaNotifier struct {
+	config    *config.Config
+	catalog   Catalog
+	newWriter func() kafkaWriter
+}
+
+// NewKafkaNotifier initializes a KafkaNotifier
+func NewKafkaNotifier(cfg *config.Config) *KafkaNotifier {
+	n := &KafkaNotifier{config: cfg, catalog: NewCatalog(cfg)}
+	n.newWriter = n.writer
+	return n
+}
+
+// Send publishes the alert keyed by the PBX host, so all the events of a host
+// land in the same partition and keep their order
+func (n *KafkaNotifier) Send(alert *Alert) error {
+	payload, err := alert.JSON(n.catalog)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	writer := n.newWriter()
+	defer writer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	err = writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(alert.Host),
+		Value: payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka topic %s: %w", n.config.Kafka.Topic, err)
+	}
+	return nil
+}
+
+// writer creates the writer of the configured topic, authenticated with SASL
+// PLAIN when a username is set
+func (n *KafkaNotifier) writer() kafkaWriter {
+	transport := &kafka.Transport{DialTimeout: 10 * time.Second}
+	if n.config.Kafka.TLS {
+		transport.TLS = &tls.Config{}
+	}
+	if n.config.Kafka.Username != "" {
+		transport.SASL = plain.Mechanism{
+			Username: n.config.Kafka.Username,
+			Password: n.config.Kafka.Password,
+		}
+	}
+
+	return &kafka.Writer{
+		Addr:         kafka.TCP(n.config.Kafka.Brokers...),
+		Topic:        n.config.Kafka.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		Transport:    transport,
+	}
+}
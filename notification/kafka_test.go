@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeKafkaWriter records the messages written instead of sending them to a
+// broker
+type fakeKafkaWriter struct {
+	messages []kafka.Message
+	closed   bool
+}
+
+func (w *fakeKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestKafkaNotifierWriter(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kafka.Brokers = []string{"10.0.0.1:9092", "10.0.0.2:9092"}
+	cfg.Kafka.Topic = "parsewatchdog.alerts"
+
+	writer, ok := NewKafkaNotifier(cfg).writer().(*kafka.Writer)
+	if !ok {
+		t.Fatalf("writer is not a *kafka.Writer")
+	}
+	if writer.Topic != cfg.Kafka.Topic {
+		t.Errorf("topic = %q, want %q", writer.Topic, cfg.Kafka.Topic)
+	}
+	if got := writer.Addr.String(); got != "10.0.0.1:9092,10.0.0.2:9092" {
+		t.Errorf("brokers = %q", got)
+	}
+	if _, ok := writer.Balancer.(*kafka.Hash); !ok {
+		t.Errorf("balancer = %T, want *kafka.Hash so the key selects the partition", writer.Balancer)
+	}
+}
+
+func TestKafkaNotifierSend(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kafka.Topic = "parsewatchdog.alerts"
+
+	fake := &fakeKafkaWriter{}
+	n := NewKafkaNotifier(cfg)
+	n.newWriter = func() kafkaWriter { return fake }
+
+	alert := &Alert{
+		ID:         "pbx1-20241103T130506",
+		Host:       "pbx1",
+		Timestamp:  "2024-11-03 13:05:06",
+		Extensions: []string{"1101", "1102"},
+		Severity:   SeverityCritical,
+	}
+	if err := n.Send(alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(fake.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(fake.messages))
+	}
+	if !fake.closed {
+		t.Errorf("writer not closed")
+	}
+	msg := fake.messages[0]
+	if string(msg.Key) != "pbx1" {
+		t.Errorf("key = %q, want the PBX host", msg.Key)
+	}
+
+	var event alertEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if event.Event != "alert" || event.ID != alert.ID || event.Kind != KindMassDisconnection || event.Host != "pbx1" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.Severity != SeverityCritical || event.TotalExtensions != 2 || event.Resolved {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// MattermostNotifier manages Mattermost notifications
+type MattermostNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewMattermostNotifier initializes a MattermostNotifier
+func NewMattermostNotifier(cfg *config.Config) *MattermostNotifier {
+	return &MattermostNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send posts a message attachment to the Mattermost incoming webhook
+func (n *MattermostNotifier) Send(alert *Alert) error {
+	attachment := map[string]interface{}{
+		"fallback": alert.Subject(n.catalog),
+		"color":    fmt.Sprintf("#%06X", alert.Color()),
+		"title":    "🚨 " + alert.Title(n.catalog),
+	}
+
+	// The other kinds show their own message, e.g. with the attempts of a
+	// brute-force alert
+	if alert.Type() != KindMassDisconnection {
+		attachment["text"] = alert.Message(n.catalog)
+	} else {
+		fields := []map[string]interface{}{
+			{"short": true, "title": "📅 " + n.catalog.T("alert.time"), "value": alert.Timestamp},
+			{"short": true, "title": "🔢 " + n.catalog.T("alert.total"), "value": strconv.Itoa(alert.TotalExtensions())},
+			{"short": false, "title": "📋 " + n.catalog.T("alert.list"), "value": "• " + strings.Join(alert.Extensions, "\n• ")},
+		}
+		if len(alert.Remediation) > 0 {
+			fields = append(fields, map[string]interface{}{
+				"short": false,
+				"title": "🛠 " + n.catalog.T("remediation.title"),
+				"value": "• " + strings.ReplaceAll(alert.RemediationSummary(n.catalog), "\n", "\n• "),
+			})
+		}
+		attachment["fields"] = fields
+	}
+
+	data := map[string]interface{}{
+		"attachments": []interface{}{attachment},
+	}
+	if n.config.Mattermost.Channel != "" {
+		data["channel"] = n.config.Mattermost.Channel
+	}
+	if n.config.Mattermost.Username != "" {
+		data["username"] = n.config.Mattermost.Username
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode Mattermost message: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", n.config.Mattermost.WebhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending message to Mattermost: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	paho5 "github.com/eclipse/paho.golang/paho"
+	paho3 "github.com/eclipse/paho.mqtt.golang"
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// PBX status values published on <topic_prefix>/<host>/status
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusOutage   = "outage"
+)
+
+// mqttTimeout bounds every MQTT network operation
+const mqttTimeout = 10 * time.Second
+
+// mqttMessage is a single message to publish
+type mqttMessage struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+// MQTTNotifier publishes alerts and per PBX status topics to an MQTT broker
+type MQTTNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewMQTTNotifier initializes an MQTTNotifier
+func NewMQTTNotifier(cfg *config.Config) *MQTTNotifier {
+	return &MQTTNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send publishes the alert JSON on <topic_prefix>/<host>/alerts
+func (n *MQTTNotifier) Send(alert *Alert) error {
+	payload, err := alert.JSON(n.catalog)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	return n.publish(mqttMessage{topic: n.topic(alert.Host, "alerts"), payload: payload})
+}
+
+// PublishStatus publishes the retained status and unreachable count of a PBX
+func (n *MQTTNotifier) PublishStatus(host string, unreachable int) error {
+	status := StatusOK
+	if unreachable >= n.config.MQTT.OutageThreshold {
+		status = StatusOutage
+	} else if unreachable > 0 {
+		status = StatusDegraded
+	}
+
+	return n.publish(
+		mqttMessage{topic: n.topic(host, "status"), payload: []byte(status), retained: n.config.MQTT.Retain},
+		mqttMessage{topic: n.topic(host, "unreachable"), payload: []byte(strconv.Itoa(unreachable)), retained: n.config.MQTT.Retain},
+	)
+}
+
+// topic builds <topic_prefix>/<host>/<name>
+func (n *MQTTNotifier) topic(host, name string) string {
+	return fmt.Sprintf("%s/%s/%s", strings.TrimRight(n.config.MQTT.TopicPrefix, "/"), host, name)
+}
+
+// publish connects with the configured protocol version and sends messages
+func (n *MQTTNotifier) publish(messages ...mqttMessage) error {
+	tlsConfig, err := n.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if n.config.MQTT.Version == "5" {
+		return n.publishV5(tlsConfig, messages)
+	}
+	return n.publishV311(tlsConfig, messages)
+}
+
+// publishV311 publishes using MQTT 3.1.1
+func (n *MQTTNotifier) publishV311(tlsConfig *tls.Config, messages []mqttMessage) error {
+	options := paho3.NewClientOptions().
+		AddBroker(n.config.MQTT.Broker).
+		SetClientID(n.config.MQTT.ClientID).
+		SetUsername(n.config.MQTT.Username).
+		SetPassword(n.config.MQTT.Password).
+		SetProtocolVersion(4).
+		SetConnectTimeout(mqttTimeout).
+		SetAutoReconnect(false)
+	if tlsConfig != nil {
+		options.SetTLSConfig(tlsConfig)
+	}
+
+	client := paho3.NewClient(options)
+	if token := client.Connect(); !token.WaitTimeout(mqttTimeout) || token.Error() != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %v", token.Error())
+	}
+	defer client.Disconnect(250)
+
+	for _, msg := range messages {
+		token := client.Publish(msg.topic, byte(n.config.MQTT.QoS), msg.retained, msg.payload)
+		if !token.WaitTimeout(mqttTimeout) {
+			return fmt.Errorf("timeout publishing to MQTT topic %s", msg.topic)
+		}
+		if err := token.Error(); err != nil {
+			return fmt.Errorf("failed to publish to MQTT topic %s: %w", msg.topic, err)
+		}
+	}
+	return nil
+}
+
+// publishV5 publishes using MQTT 5
+func (n *MQTTNotifier) publishV5(tlsConfig *tls.Config, messages []mqttMessage) error {
+	broker, err := url.Parse(n.config.MQTT.Broker)
+	if err != nil {
+		return fmt.Errorf("invalid MQTT broker URL: %w", err)
+	}
+
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	var conn net.Conn
+	if tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", broker.Host, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", broker.Host)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mqttTimeout)
+	defer cancel()
+
+	client := paho5.NewClient(paho5.ClientConfig{Conn: conn})
+	connect := &paho5.Connect{
+		ClientID:   n.config.MQTT.ClientID,
+		KeepAlive:  30,
+		CleanStart: true,
+	}
+	if n.config.MQTT.Username != "" {
+		connect.Username = n.config.MQTT.Username
+		connect.UsernameFlag = true
+		connect.Password = []byte(n.config.MQTT.Password)
+		connect.PasswordFlag = true
+	}
+
+	connack, err := client.Connect(ctx, connect)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	if connack.ReasonCode != 0 {
+		conn.Close()
+		return fmt.Errorf("MQTT broker refused the connection: reason code %d", connack.ReasonCode)
+	}
+	defer client.Disconnect(&paho5.Disconnect{ReasonCode: 0})
+
+	for _, msg := range messages {
+		_, err := client.Publish(ctx, &paho5.Publish{
+			Topic:   msg.topic,
+			QoS:     byte(n.config.MQTT.QoS),
+			Retain:  msg.retained,
+			Payload: msg.payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish to MQTT topic %s: %w", msg.topic, err)
+		}
+	}
+	return nil
+}
+
+// tlsConfig returns the TLS settings for ssl://, tls:// and mqtts:// brokers
+func (n *MQTTNotifier) tlsConfig() (*tls.Config, error) {
+	scheme, _, _ := strings.Cut(n.config.MQTT.Broker, "://")
+	if scheme != "ssl" && scheme != "tls" && scheme != "mqtts" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: n.config.MQTT.TLSInsecure}
+	if n.config.MQTT.TLSCA != "" {
+		pem, err := os.ReadFile(n.config.MQTT.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", n.config.MQTT.TLSCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
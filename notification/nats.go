@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSNotifier publishes the structured alert JSON to a NATS subject, using
+// core NATS or JetStream
+type NATSNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewNATSNotifier initializes a NATSNotifier
+func NewNATSNotifier(cfg *config.Config) *NATSNotifier {
+	return &NATSNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send publishes the alert and waits for the server to process it
+func (n *NATSNotifier) Send(alert *Alert) error {
+	payload, err := alert.JSON(n.catalog)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	options := []nats.Option{nats.Name("parsewatchdog"), nats.Timeout(10 * time.Second)}
+	if n.config.NATS.CredsFile != "" {
+		options = append(options, nats.UserCredentials(n.config.NATS.CredsFile))
+	}
+	if n.config.NATS.Username != "" {
+		options = append(options, nats.UserInfo(n.config.NATS.Username, n.config.NATS.Password))
+	}
+
+	nc, err := nats.Connect(n.config.NATS.URL, options...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	defer nc.Close()
+
+	if !n.config.NATS.JetStream {
+		if err := nc.Publish(n.config.NATS.Subject, payload); err != nil {
+			return fmt.Errorf("failed to publish to NATS subject %s: %w", n.config.NATS.Subject, err)
+		}
+		return nc.FlushTimeout(10 * time.Second)
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The message ID lets JetStream drop duplicates of the same event
+	msgID := alert.ID
+	if alert.Resolved {
+		msgID += "-resolved"
+	}
+	if _, err := js.Publish(ctx, n.config.NATS.Subject, payload, jetstream.WithMsgID(msgID)); err != nil {
+		return fmt.Errorf("failed to publish to JetStream subject %s: %w", n.config.NATS.Subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// startNATSServer runs an embedded NATS server, with JetStream enabled
+func startNATSServer(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("starting NATS server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatalf("NATS server not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+// checkAlertPayload decodes the alert JSON and checks its main fields
+func checkAlertPayload(t *testing.T, data []byte, alert *Alert) {
+	t.Helper()
+	var event alertEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if event.ID != alert.ID || event.Host != alert.Host || event.Resolved != alert.Resolved || event.TotalExtensions != len(alert.Extensions) {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestNATSNotifierCore(t *testing.T) {
+	s := startNATSServer(t)
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer nc.Close()
+	sub, err := nc.SubscribeSync("parsewatchdog.alerts")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	nc.Flush()
+
+	cfg := &config.Config{}
+	cfg.NATS.URL = s.ClientURL()
+	cfg.NATS.Subject = "parsewatchdog.alerts"
+
+	alert := &Alert{ID: "pbx1-20241103T130506", Host: "pbx1", Timestamp: "2024-11-03 13:05:06", Extensions: []string{"1101", "1102"}}
+	if err := NewNATSNotifier(cfg).Send(alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msg, err := sub.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("no message received: %v", err)
+	}
+	if msg.Subject != cfg.NATS.Subject {
+		t.Errorf("subject = %q, want %q", msg.Subject, cfg.NATS.Subject)
+	}
+	checkAlertPayload(t, msg.Data, alert)
+}
+
+func TestNATSNotifierJetStream(t *testing.T) {
+	s := startNATSServer(t)
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "ALERTS", Subjects: []string{"parsewatchdog.>"}})
+	if err != nil {
+		t.Fatalf("create stream: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.NATS.URL = s.ClientURL()
+	cfg.NATS.Subject = "parsewatchdog.alerts"
+	cfg.NATS.JetStream = true
+
+	alert := &Alert{ID: "pbx1-20241103T130506", Host: "pbx1", Timestamp: "2024-11-03 13:05:06", Extensions: []string{"1101"}}
+	n := NewNATSNotifier(cfg)
+	// The duplicate is dropped thanks to the message ID, the resolution is not
+	if err := n.Send(alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := n.Send(alert); err != nil {
+		t.Fatalf("Send duplicate: %v", err)
+	}
+	resolved := *alert
+	resolved.Resolved = true
+	if err := n.Send(&resolved); err != nil {
+		t.Fatalf("Send resolved: %v", err)
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	if info.State.Msgs != 2 {
+		t.Fatalf("stream has %d messages, want 2", info.State.Msgs)
+	}
+
+	for seq, want := range map[uint64]*Alert{1: alert, 2: &resolved} {
+		msg, err := stream.GetMsg(ctx, seq)
+		if err != nil {
+			t.Fatalf("get message %d: %v", seq, err)
+		}
+		if msg.Subject != cfg.NATS.Subject {
+			t.Errorf("subject = %q, want %q", msg.Subject, cfg.NATS.Subject)
+		}
+		checkAlertPayload(t, msg.Data, want)
+	}
+}
@@ -2,34 +2,169 @@ package notification
 
 import (
 	"log"
+	"time"
 
 	"github.com/lordbasex/parsewatchdog/config"
 )
 
-func NotifyAll(cfg *config.Config, subject, message string) {
-	if cfg.SMTP.Enabled {
-		if err := NewEmailNotifier(cfg).Send(subject, message); err != nil {
+// NotifyAll sends the alert through the channels of its endpoint group or of
+// the matching routes, or through every enabled channel when none applies.
+// Informational alerts are never sent to the paging channels.
+func NotifyAll(cfg *config.Config, alert *Alert) {
+	route := routeOf(cfg, alert, time.Now())
+	alert.route = route
+	cfg = configFor(cfg, alert)
+	paging := alert.pages()
+	if cfg.SMTP.Enabled && route.sends("smtp") {
+		if err := NewEmailNotifier(cfg).Send(alert); err != nil {
 			log.Println("Error sending email:", err)
 		}
 	}
-	if cfg.Telegram.Enabled {
-		if err := NewTelegramNotifier(cfg).Send(subject, message); err != nil {
+	if cfg.Telegram.Enabled && route.sends("telegram") {
+		if err := NewTelegramNotifier(cfg).Send(alert); err != nil {
 			log.Println("Error sending Telegram message:", err)
 		}
 	}
-	if cfg.API.Enabled {
-		if err := NewAPINotifier(cfg).Send(subject, message); err != nil {
+	if cfg.API.Enabled && route.sends("api") {
+		if err := NewAPINotifier(cfg).Send(alert); err != nil {
 			log.Println("Error sending API notification:", err)
 		}
 	}
-	if cfg.RabbitMQ.Enabled {
-		if err := NewRabbitMQNotifier(cfg).Send(subject, message); err != nil {
+	if cfg.RabbitMQ.Enabled && route.sends("rabbitmq") {
+		if err := NewRabbitMQNotifier(cfg).Send(alert); err != nil {
 			log.Println("Error sending RabbitMQ notification:", err)
 		}
 	}
-	if cfg.Slack.Enabled {
-		if err := NewSlackNotifier(cfg).Send(subject, message); err != nil {
+	if cfg.Slack.Enabled && route.sends("slack") {
+		if err := NewSlackNotifier(cfg).Send(alert); err != nil {
 			log.Println("Error sending Slack notification:", err)
 		}
 	}
+	if cfg.Teams.Enabled && route.sends("teams") {
+		if err := NewTeamsNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending Teams notification:", err)
+		}
+	}
+	if cfg.Discord.Enabled && route.sends("discord") {
+		if err := NewDiscordNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending Discord notification:", err)
+		}
+	}
+	if cfg.Mattermost.Enabled && route.sends("mattermost") {
+		if err := NewMattermostNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending Mattermost notification:", err)
+		}
+	}
+	if paging && cfg.PagerDuty.Enabled && route.sends("pagerduty") {
+		if err := NewPagerDutyNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending PagerDuty event:", err)
+		}
+	}
+	if paging && cfg.Opsgenie.Enabled && route.sends("opsgenie") {
+		if err := NewOpsgenieNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending Opsgenie alert:", err)
+		}
+	}
+	if paging && cfg.SMS.Enabled && route.sends("sms") {
+		if err := NewSMSNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending SMS notification:", err)
+		}
+	}
+	if paging && cfg.Voice.Enabled && route.sends("voice") {
+		if err := NewVoiceNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error placing voice call:", err)
+		}
+	}
+	notifySyslog(cfg, route, alert)
+	notifyEventBus(cfg, route, alert)
+	if cfg.Exec.Enabled && route.sends("exec") {
+		if err := NewExecNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error running exec notifier:", err)
+		}
+	}
+	for _, webhook := range cfg.Webhooks {
+		if !webhook.Enabled || !route.sends("webhook."+webhook.Name) {
+			continue
+		}
+		if err := NewWebhookNotifier(cfg, webhook).Send(alert); err != nil {
+			log.Printf("Error sending webhook %s notification: %v", webhook.Name, err)
+		}
+	}
+}
+
+// NotifyResolved closes the incident of a resolved alert on the channels that
+// track the alert lifecycle, among those the alert was sent through
+func NotifyResolved(cfg *config.Config, alert *Alert) {
+	route := alert.route
+	cfg = configFor(cfg, alert)
+	if cfg.PagerDuty.Enabled && route.sends("pagerduty") {
+		if err := NewPagerDutyNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending PagerDuty event:", err)
+		}
+	}
+	if cfg.Opsgenie.Enabled && route.sends("opsgenie") {
+		if err := NewOpsgenieNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error sending Opsgenie alert:", err)
+		}
+	}
+	notifySyslog(cfg, route, alert)
+	notifyEventBus(cfg, route, alert)
+	if cfg.Exec.Enabled && route.sends("exec") {
+		if err := NewExecNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error running exec notifier:", err)
+		}
+	}
+	for _, webhook := range cfg.Webhooks {
+		if !webhook.Enabled || !webhook.Resolved || !route.sends("webhook."+webhook.Name) {
+			continue
+		}
+		if err := NewWebhookNotifier(cfg, webhook).Send(alert); err != nil {
+			log.Printf("Error sending webhook %s notification: %v", webhook.Name, err)
+		}
+	}
+}
+
+// notifySyslog writes the alert to syslog and, when enabled or running under
+// systemd, to the journal
+func notifySyslog(cfg *config.Config, route channels, alert *Alert) {
+	if !cfg.Syslog.Enabled || !route.sends("syslog") {
+		return
+	}
+	if err := NewSyslogNotifier(cfg).Send(alert); err != nil {
+		log.Println("Error sending syslog message:", err)
+	}
+	if cfg.Syslog.Journald == "true" || (cfg.Syslog.Journald == "auto" && JournaldAvailable()) {
+		if err := NewJournaldNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error writing journal entry:", err)
+		}
+	}
+}
+
+// notifyEventBus publishes the structured alert to the enabled event buses
+func notifyEventBus(cfg *config.Config, route channels, alert *Alert) {
+	if cfg.Kafka.Enabled && route.sends("kafka") {
+		if err := NewKafkaNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error publishing Kafka message:", err)
+		}
+	}
+	if cfg.NATS.Enabled && route.sends("nats") {
+		if err := NewNATSNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error publishing NATS message:", err)
+		}
+	}
+	if cfg.MQTT.Enabled && route.sends("mqtt") {
+		if err := NewMQTTNotifier(cfg).Send(alert); err != nil {
+			log.Println("Error publishing MQTT message:", err)
+		}
+	}
+}
+
+// PublishStatus publishes the current status of the PBX on the channels that
+// keep a live status, such as the MQTT status topics
+func PublishStatus(cfg *config.Config, host string, unreachable int) {
+	if cfg.MQTT.Enabled {
+		if err := NewMQTTNotifier(cfg).PublishStatus(host, unreachable); err != nil {
+			log.Println("Error publishing MQTT status:", err)
+		}
+	}
 }
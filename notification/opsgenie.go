@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// opsgenieMessageLimit is the maximum length of the alert message
+const opsgenieMessageLimit = 130
+
+// OpsgenieNotifier manages Opsgenie alerts
+type OpsgenieNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewOpsgenieNotifier initializes an OpsgenieNotifier
+func NewOpsgenieNotifier(cfg *config.Config) *OpsgenieNotifier {
+	return &OpsgenieNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send creates an Opsgenie alert using the incident ID as alias, or closes it
+// when the alert is resolved
+func (n *OpsgenieNotifier) Send(alert *Alert) error {
+	baseURL := strings.TrimRight(n.config.Opsgenie.URL, "/") + "/v2/alerts"
+
+	var endpoint string
+	var payload map[string]interface{}
+	if alert.Resolved {
+		endpoint = fmt.Sprintf("%s/%s/close?identifierType=alias", baseURL, url.PathEscape(alert.ID))
+		payload = map[string]interface{}{
+			"source": "ParseWatchdog",
+			"note":   alert.ResolvedText(n.catalog),
+		}
+	} else {
+		endpoint = baseURL
+		payload = map[string]interface{}{
+			"message":     truncate(alert.Subject(n.catalog), opsgenieMessageLimit),
+			"alias":       alert.ID,
+			"description": alert.Message(n.catalog),
+			"priority":    n.priority(alert),
+			"tags":        append([]string{alert.Host}, n.config.Opsgenie.Tags...),
+			"entity":      alert.Host,
+			"source":      "ParseWatchdog",
+			"details": map[string]string{
+				"timestamp":        alert.Timestamp,
+				"total_extensions": fmt.Sprintf("%d", alert.TotalExtensions()),
+				"extensions":       strings.Join(alert.Extensions, ", "),
+			},
+		}
+		if n.config.Opsgenie.Team != "" {
+			payload["responders"] = []map[string]string{{"type": "team", "name": n.config.Opsgenie.Team}}
+		}
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode Opsgenie alert: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+n.config.Opsgenie.APIKey)
+
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending Opsgenie alert: %w", err)
+	}
+	return nil
+}
+
+// priority derives the Opsgenie priority (P1-P5) from the number of
+// disconnected extensions
+func (n *OpsgenieNotifier) priority(alert *Alert) string {
+	// A critical trunk down is a single entity but must page as P1
+	if alert.Type() == KindTrunkDown && alert.AtLeast(SeverityCritical) {
+		return "P1"
+	}
+
+	total := alert.TotalExtensions()
+	thresholds := []int{
+		n.config.Opsgenie.P1Threshold,
+		n.config.Opsgenie.P2Threshold,
+		n.config.Opsgenie.P3Threshold,
+		n.config.Opsgenie.P4Threshold,
+	}
+	for i, threshold := range thresholds {
+		if threshold > 0 && total >= threshold {
+			return fmt.Sprintf("P%d", i+1)
+		}
+	}
+	return "P5"
+}
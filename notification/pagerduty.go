@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// PagerDutyNotifier manages PagerDuty Events API v2 notifications
+type PagerDutyNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewPagerDutyNotifier initializes a PagerDutyNotifier
+func NewPagerDutyNotifier(cfg *config.Config) *PagerDutyNotifier {
+	return &PagerDutyNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send triggers a PagerDuty incident, or resolves it when the alert is
+// resolved. The alert ID is used as dedup_key so both events match.
+func (n *PagerDutyNotifier) Send(alert *Alert) error {
+	event := map[string]interface{}{
+		"routing_key": n.config.PagerDuty.RoutingKey,
+		"dedup_key":   alert.ID,
+	}
+
+	if alert.Resolved {
+		event["event_action"] = "resolve"
+	} else {
+		event["event_action"] = "trigger"
+		event["payload"] = map[string]interface{}{
+			"summary":   alert.Subject(n.catalog),
+			"source":    alert.Host,
+			"severity":  n.severity(alert.Level()),
+			"component": "asterisk",
+			"class":     alert.Type(),
+			"custom_details": map[string]interface{}{
+				"timestamp":        alert.Timestamp,
+				"total_extensions": alert.TotalExtensions(),
+				"extensions":       alert.Extensions,
+				"reason":           alert.Reason,
+				"detail":           alert.Detail,
+			},
+		}
+	}
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode PagerDuty event: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", n.config.PagerDuty.URL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending PagerDuty event: %w", err)
+	}
+	return nil
+}
+
+// severity maps an alert severity to the configured PagerDuty severity
+func (n *PagerDutyNotifier) severity(level string) string {
+	switch level {
+	case SeverityInfo:
+		return n.config.PagerDuty.SeverityInfo
+	case SeverityCritical:
+		return n.config.PagerDuty.SeverityCritical
+	default:
+		return n.config.PagerDuty.SeverityWarning
+	}
+}
@@ -10,17 +10,18 @@ import (
 )
 
 type RabbitMQNotifier struct {
-	config *config.Config
+	config  *config.Config
+	catalog Catalog
 }
 
 // NewRabbitMQNotifier initializes the RabbitMQ notifier
 func NewRabbitMQNotifier(cfg *config.Config) *RabbitMQNotifier {
-	return &RabbitMQNotifier{config: cfg}
+	return &RabbitMQNotifier{config: cfg, catalog: NewCatalog(cfg)}
 }
 
 // Send sends a JSON message to a RabbitMQ queue
 
-func (n *RabbitMQNotifier) Send(subject, message string) error {
+func (n *RabbitMQNotifier) Send(alert *Alert) error {
 	// Corregir la cadena de conexión usando %d para el puerto si es un entero
 	connStr := fmt.Sprintf("%s://%s:%s@%s:%d/",
 		n.config.RabbitMQ.Type,
@@ -63,8 +64,8 @@ func (n *RabbitMQNotifier) Send(subject, message string) error {
 
 	// Create the JSON message payload
 	payload := map[string]string{
-		"subject": subject,
-		"message": message,
+		"subject": alert.Subject(n.catalog),
+		"message": alert.Message(n.catalog),
 	}
 	jsonMessage, err := json.Marshal(payload)
 	if err != nil {
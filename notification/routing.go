@@ -0,0 +1,214 @@
+package notification
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// channelNames are the notification channels that can be selected by name,
+// besides webhook.<name>
+var channelNames = []string{
+	"smtp", "telegram", "api", "rabbitmq", "slack", "teams", "discord", "mattermost", "pagerduty",
+	"opsgenie", "sms", "voice", "syslog", "kafka", "nats", "mqtt", "exec",
+}
+
+// alertKinds are the kinds accepted by the routes
+var alertKinds = []string{KindMassDisconnection, KindTrunkDown, KindBruteForce, KindLatency, KindFlapping, KindRemediation}
+
+// dayNames are the week days accepted by the routes, indexed by time.Weekday
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// channels is the set of channels an alert is sent through. Nil means every
+// enabled channel.
+type channels []string
+
+// sends reports whether the alert is sent through the channel name
+func (c channels) sends(name string) bool {
+	if c == nil {
+		return true
+	}
+	if slices.Contains(c, name) {
+		return true
+	}
+	// "webhook" selects all the webhooks
+	return strings.HasPrefix(name, "webhook.") && slices.Contains(c, "webhook")
+}
+
+// intersect returns the channels sent by both c and other, keeping the
+// specific webhook.<name> when the other one selects all the webhooks
+func (c channels) intersect(other channels) channels {
+	both := channels{}
+	for _, set := range [][]string{c, other} {
+		for _, name := range set {
+			if c.sends(name) && other.sends(name) && !slices.Contains(both, name) {
+				both = append(both, name)
+			}
+		}
+	}
+	return both
+}
+
+// groupOf returns the configuration of the endpoint group of the alert, if any
+func groupOf(cfg *config.Config, alert *Alert) *config.GroupConfig {
+	if alert.Group == "" {
+		return nil
+	}
+	for i := range cfg.Groups {
+		if cfg.Groups[i].Name == alert.Group {
+			return &cfg.Groups[i]
+		}
+	}
+	return nil
+}
+
+// configFor returns the configuration used to send an alert, with the
+// recipients of its endpoint group when set
+func configFor(cfg *config.Config, alert *Alert) *config.Config {
+	group := groupOf(cfg, alert)
+	if group == nil {
+		return cfg
+	}
+	routed := *cfg
+	if len(group.SMTPRecipients) > 0 {
+		routed.SMTP.Recipients = group.SMTPRecipients
+	}
+	if len(group.SMSRecipients) > 0 {
+		routed.SMS.Recipients = group.SMSRecipients
+	}
+	if len(group.VoiceNumbers) > 0 {
+		routed.Voice.Numbers = group.VoiceNumbers
+	}
+	return &routed
+}
+
+// routeOf returns the channels of an alert at now: those of the matching
+// routes, restricted to the channels of its endpoint group when set. It
+// returns nil, i.e. every enabled channel, when neither applies.
+func routeOf(cfg *config.Config, alert *Alert, now time.Time) channels {
+	route := routesOf(cfg, alert, now)
+	if group := groupOf(cfg, alert); group != nil && len(group.Channels) > 0 {
+		if route == nil {
+			return channels(group.Channels)
+		}
+		return route.intersect(channels(group.Channels))
+	}
+	return route
+}
+
+// routesOf returns the channels of the routes matching an alert at now, nil
+// when none matches
+func routesOf(cfg *config.Config, alert *Alert, now time.Time) channels {
+	var route channels
+	matched := false
+	for _, r := range cfg.Routes {
+		if !matches(r, alert, now) {
+			continue
+		}
+		matched = true
+		for _, name := range r.Channels {
+			if !slices.Contains(route, name) {
+				route = append(route, name)
+			}
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	// Matching routes without channels drop the alert
+	if matched && route == nil {
+		return channels{}
+	}
+	return route
+}
+
+// matches reports whether the alert meets all the conditions of the route
+func matches(r config.RouteConfig, alert *Alert, now time.Time) bool {
+	if len(r.Severities) > 0 && !slices.Contains(r.Severities, alert.Level()) {
+		return false
+	}
+	if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, alert.Type()) {
+		return false
+	}
+	if len(r.Groups) > 0 && !slices.Contains(r.Groups, alert.Group) {
+		return false
+	}
+	if len(r.Days) > 0 && !slices.Contains(r.Days, dayNames[now.Weekday()]) {
+		return false
+	}
+	if r.Hours != "" {
+		from, to, err := parseHours(r.Hours)
+		if err != nil {
+			return false
+		}
+		minute := now.Hour()*60 + now.Minute()
+		if from <= to {
+			return minute >= from && minute < to
+		}
+		// Ranges such as 22:00-06:00 span midnight
+		return minute >= from || minute < to
+	}
+	return true
+}
+
+// parseHours parses a time of day range such as 08:00-18:00 into minutes
+// since midnight
+func parseHours(value string) (int, int, error) {
+	fromText, toText, ok := strings.Cut(value, "-")
+	from, fromErr := time.Parse("15:04", strings.TrimSpace(fromText))
+	to, toErr := time.Parse("15:04", strings.TrimSpace(toText))
+	if !ok || fromErr != nil || toErr != nil {
+		return 0, 0, fmt.Errorf("invalid hours %q, expected e.g. 08:00-18:00", value)
+	}
+	return from.Hour()*60 + from.Minute(), to.Hour()*60 + to.Minute(), nil
+}
+
+// ValidateChannels checks that names are known notification channels or
+// configured webhooks
+func ValidateChannels(cfg *config.Config, names []string) error {
+	for _, name := range names {
+		if slices.Contains(channelNames, name) || name == "webhook" {
+			continue
+		}
+		if webhook, ok := strings.CutPrefix(name, "webhook."); ok {
+			if slices.ContainsFunc(cfg.Webhooks, func(w config.WebhookConfig) bool { return w.Name == webhook }) {
+				continue
+			}
+		}
+		return fmt.Errorf("unknown notification channel %q", name)
+	}
+	return nil
+}
+
+// ValidateRoutes checks the conditions and channels of the routes
+func ValidateRoutes(cfg *config.Config) error {
+	for _, r := range cfg.Routes {
+		for _, severity := range r.Severities {
+			if severity != SeverityInfo && severity != SeverityWarning && severity != SeverityCritical {
+				return fmt.Errorf("route %s: invalid severity %q", r.Name, severity)
+			}
+		}
+		for _, kind := range r.Kinds {
+			if !slices.Contains(alertKinds, kind) {
+				return fmt.Errorf("route %s: invalid kind %q", r.Name, kind)
+			}
+		}
+		for _, day := range r.Days {
+			if !slices.Contains(dayNames, day) {
+				return fmt.Errorf("route %s: invalid day %q, expected e.g. mon", r.Name, day)
+			}
+		}
+		if r.Hours != "" {
+			if _, _, err := parseHours(r.Hours); err != nil {
+				return fmt.Errorf("route %s: %w", r.Name, err)
+			}
+		}
+		if err := ValidateChannels(cfg, r.Channels); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
@@ -12,28 +12,31 @@ import (
 
 // SlackNotifier manages Slack notifications
 type SlackNotifier struct {
-	config *config.Config
+	config  *config.Config
+	catalog Catalog
 }
 
 // NewSlackNotifier initializes a SlackNotifier
 func NewSlackNotifier(cfg *config.Config) *SlackNotifier {
-	return &SlackNotifier{config: cfg}
+	return &SlackNotifier{config: cfg, catalog: NewCatalog(cfg)}
 }
 
 // Send formats and sends a structured message to Slack
-func (n *SlackNotifier) Send(subject, message string) error {
-	// Parse message to extract timestamp, totalExtensions, and extensions
-	parsedData, err := parseSlackMessage(message)
-	if err != nil {
-		return fmt.Errorf("error parsing message: %v", err)
+func (n *SlackNotifier) Send(alert *Alert) error {
+	// Formatted message for Slack. The other kinds show their own message,
+	// e.g. with the attempts of a brute-force alert.
+	formattedMessage := fmt.Sprintf("🚨 *%s* 🚨\n\n%s", alert.Title(n.catalog), alert.Message(n.catalog))
+	if alert.Type() == KindMassDisconnection {
+		formattedMessage = fmt.Sprintf("🚨 *%s* 🚨\n\n📅 *%s:* %s\n🔢 *%s:* %d\n📋 *%s:*\n%s",
+			alert.Title(n.catalog),
+			n.catalog.T("alert.time"), alert.Timestamp,
+			n.catalog.T("alert.total"), alert.TotalExtensions(),
+			n.catalog.T("alert.list"), strings.Join(alert.Extensions, "\n• "))
+		if len(alert.Remediation) > 0 {
+			formattedMessage += fmt.Sprintf("\n\n🛠 *%s:*\n%s", n.catalog.T("remediation.title"), alert.RemediationSummary(n.catalog))
+		}
 	}
 
-	// Formatted message for Slack
-	formattedMessage := fmt.Sprintf("🚨 *Mass Disconnection Alert* 🚨\n\n📅 *Time:* %s\n🔢 *Total Extensions Disconnected:* %s\n📋 *Extensions List:*\n%s",
-		parsedData["Timestamp"],
-		parsedData["TotalExtensions"],
-		parsedData["Extensions"])
-
 	// Prepare data for Slack webhook
 	data := map[string]string{
 		"text": formattedMessage,
@@ -49,37 +52,8 @@ func (n *SlackNotifier) Send(subject, message string) error {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("error sending message to Slack: status code %d", resp.StatusCode)
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending message to Slack: %w", err)
 	}
 	return nil
 }
-
-func parseSlackMessage(message string) (map[string]string, error) {
-	lines := strings.Split(message, "\n")
-	if len(lines) < 3 {
-		return nil, fmt.Errorf("message format is invalid")
-	}
-
-	// Extraer campos asumiendo un formato específico
-	timestamp := strings.TrimSpace(strings.TrimPrefix(lines[0], "Mass disconnection detected at"))
-	totalExtensionsStr := strings.TrimSpace(strings.TrimPrefix(lines[1], "Total: "))
-	totalExtensions := strings.Split(totalExtensionsStr, " ")[0]
-	extensions := strings.TrimSpace(strings.TrimPrefix(lines[2], "Extensions:"))
-
-	// Formatear la lista de extensiones para Slack
-	formattedExtensions := strings.ReplaceAll(extensions, ", ", "\n• ")
-
-	return map[string]string{
-		"Timestamp":       timestamp,
-		"TotalExtensions": totalExtensions,
-		"Extensions":      formattedExtensions,
-	}, nil
-}
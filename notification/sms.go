@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// smsLimiter keeps the send time of the SMS delivered during the last hour,
+// shared by every SMSNotifier so the limit applies across alerts
+var smsLimiter = struct {
+	sync.Mutex
+	sent []time.Time
+}{}
+
+// SMSNotifier sends SMS through a Twilio compatible API or a generic HTTP gateway
+type SMSNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewSMSNotifier initializes an SMSNotifier
+func NewSMSNotifier(cfg *config.Config) *SMSNotifier {
+	return &SMSNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send delivers the alert text to every recipient, honouring the hourly limit
+func (n *SMSNotifier) Send(alert *Alert) error {
+	text := n.text(alert)
+
+	var errs []string
+	for _, recipient := range n.config.SMS.Recipients {
+		if !allowSMS(n.config.SMS.MaxPerHour) {
+			log.Printf("SMS rate limit reached (%d per hour), skipping %s", n.config.SMS.MaxPerHour, recipient)
+			continue
+		}
+
+		var err error
+		if n.config.SMS.Provider == "http" {
+			err = n.sendGateway(recipient, text)
+		} else {
+			err = n.sendTwilio(recipient, text)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", recipient, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error sending SMS: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// text builds the SMS body. When it exceeds the maximum length the extension
+// list is truncated first, so the count and host are always kept.
+func (n *SMSNotifier) text(alert *Alert) string {
+	header := []rune(fmt.Sprintf(n.catalog.T("sms.text"), alert.TotalExtensions(), alert.Host, alert.Timestamp))
+	extensions := []rune(" " + strings.Join(alert.Extensions, ","))
+	switch alert.Type() {
+	case KindTrunkDown:
+		// The trunk names are part of the header
+		header = []rune(fmt.Sprintf(n.catalog.T("sms.trunk"), strings.Join(alert.Extensions, ","), alert.Host, alert.Timestamp))
+		extensions = nil
+	case KindBruteForce:
+		header = []rune(fmt.Sprintf(n.catalog.T("sms.security"), alert.Attempts, strings.Join(alert.Extensions, ","), alert.Host, alert.Timestamp))
+		extensions = nil
+	case KindLatency:
+		header = []rune(fmt.Sprintf(n.catalog.T("sms.latency"), alert.TotalExtensions(), alert.Observed, alert.Detail, alert.Host, alert.Timestamp))
+	}
+	limit := n.config.SMS.MaxLength
+
+	if limit <= 0 || len(header)+len(extensions) <= limit {
+		return string(header) + string(extensions)
+	}
+	if len(header) >= limit {
+		return string(header[:limit])
+	}
+
+	room := limit - len(header)
+	if room <= 3 {
+		return string(header)
+	}
+	return string(header) + string(extensions[:room-3]) + "..."
+}
+
+// sendTwilio sends one message through the Twilio compatible Messages API
+func (n *SMSNotifier) sendTwilio(to, text string) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json",
+		strings.TrimRight(n.config.SMS.URL, "/"), url.PathEscape(n.config.SMS.AccountSID))
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", n.config.SMS.From)
+	form.Set("Body", text)
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(n.config.SMS.AccountSID, n.config.SMS.AuthToken)
+
+	return doRequest(req)
+}
+
+// sendGateway renders the gateway body template and sends one message
+func (n *SMSNotifier) sendGateway(to, text string) error {
+	tmpl, err := template.New("sms").Funcs(webhookFuncs).Parse(n.config.SMS.Body)
+	if err != nil {
+		return fmt.Errorf("error parsing body template: %v", err)
+	}
+
+	// Data available to the template: {{.To}}, {{.From}} and {{.Text}}
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, map[string]string{
+		"To":   to,
+		"From": n.config.SMS.From,
+		"Text": text,
+	})
+	if err != nil {
+		return fmt.Errorf("error executing body template: %v", err)
+	}
+
+	req, err := http.NewRequest(n.config.SMS.Method, n.config.SMS.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", n.config.SMS.ContentType)
+	for name, value := range n.config.SMS.Headers {
+		req.Header.Set(name, value)
+	}
+
+	return doRequest(req)
+}
+
+// allowSMS records a new SMS and reports whether it fits in the hourly limit
+func allowSMS(maxPerHour int) bool {
+	if maxPerHour <= 0 {
+		return true
+	}
+
+	smsLimiter.Lock()
+	defer smsLimiter.Unlock()
+
+	cutoff := time.Now().Add(-time.Hour)
+	recent := smsLimiter.sent[:0]
+	for _, sent := range smsLimiter.sent {
+		if sent.After(cutoff) {
+			recent = append(recent, sent)
+		}
+	}
+	smsLimiter.sent = recent
+
+	if len(smsLimiter.sent) >= maxPerHour {
+		return false
+	}
+	smsLimiter.sent = append(smsLimiter.sent, time.Now())
+	return true
+}
@@ -0,0 +1,155 @@
+package notification
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// syslogSDID is the RFC 5424 structured data ID of the alert parameters
+const syslogSDID = "parsewatchdog@32473"
+
+// syslogFacilities maps facility names to their RFC 5424 code
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogNotifier emits alerts as RFC 5424 messages with structured data
+type SyslogNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewSyslogNotifier initializes a SyslogNotifier
+func NewSyslogNotifier(cfg *config.Config) *SyslogNotifier {
+	return &SyslogNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send writes the alert to the configured syslog destination
+func (n *SyslogNotifier) Send(alert *Alert) error {
+	conn, err := n.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer conn.Close()
+
+	msg := n.format(alert)
+
+	// Stream transports use octet counting framing (RFC 5425/6587)
+	if n.config.Syslog.Network == "tcp" || n.config.Syslog.Network == "tls" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("failed to write syslog message: %w", err)
+	}
+	return nil
+}
+
+// dial opens the connection for the configured network
+func (n *SyslogNotifier) dial() (net.Conn, error) {
+	address := n.config.Syslog.Address
+	timeout := 10 * time.Second
+
+	switch n.config.Syslog.Network {
+	case "unix":
+		// Local sockets may be datagram (/dev/log) or stream oriented
+		conn, err := net.DialTimeout("unixgram", address, timeout)
+		if err != nil {
+			conn, err = net.DialTimeout("unix", address, timeout)
+		}
+		return conn, err
+	case "tls":
+		tlsConfig := &tls.Config{InsecureSkipVerify: n.config.Syslog.TLSInsecure}
+		if n.config.Syslog.TLSCA != "" {
+			pem, err := os.ReadFile(n.config.Syslog.TLSCA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", n.config.Syslog.TLSCA)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, tlsConfig)
+	default:
+		return net.DialTimeout(n.config.Syslog.Network, address, timeout)
+	}
+}
+
+// format builds the RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+func (n *SyslogNotifier) format(alert *Alert) string {
+	facility, ok := syslogFacilities[n.config.Syslog.Facility]
+	if !ok {
+		facility = syslogFacilities["daemon"]
+	}
+
+	msgID := "ALERT"
+	text := alert.Subject(n.catalog)
+	if alert.Resolved {
+		msgID = "RESOLVED"
+		text = alert.ResolvedText(n.catalog)
+	}
+
+	host := alert.Host
+	if host == "" {
+		host = "-"
+	}
+
+	params := []string{
+		sdParam("incident", alert.ID),
+		sdParam("host", alert.Host),
+		sdParam("timestamp", alert.Timestamp),
+		sdParam("tenant", alert.Tenant),
+		sdParam("group", alert.Group),
+		sdParam("severity", alert.Level()),
+		sdParam("count", strconv.Itoa(alert.TotalExtensions())),
+		sdParam("extensions", strings.Join(alert.Extensions, ",")),
+		sdParam("resolved", strconv.FormatBool(alert.Resolved)),
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s [%s %s] %s",
+		facility*8+syslogSeverity(alert),
+		time.Now().Format(time.RFC3339Nano),
+		host,
+		n.config.Syslog.AppName,
+		os.Getpid(),
+		msgID,
+		syslogSDID,
+		strings.Join(params, " "),
+		text)
+}
+
+// syslogSeverity maps the alert severity to the syslog severity code
+func syslogSeverity(alert *Alert) int {
+	if alert.Resolved {
+		return 5 // notice
+	}
+	switch alert.Level() {
+	case SeverityInfo:
+		return 6 // informational
+	case SeverityCritical:
+		return 2 // critical
+	default:
+		return 4 // warning
+	}
+}
+
+// sdParam formats a structured data parameter escaping '"', '\' and ']'
+func sdParam(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+	return fmt.Sprintf(`%s="%s"`, name, value)
+}
@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// TeamsNotifier manages Microsoft Teams notifications
+type TeamsNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewTeamsNotifier initializes a TeamsNotifier
+func NewTeamsNotifier(cfg *config.Config) *TeamsNotifier {
+	return &TeamsNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send posts an Adaptive Card to the Teams incoming webhook or Workflows URL
+func (n *TeamsNotifier) Send(alert *Alert) error {
+	body := []interface{}{
+		map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   "🚨 " + alert.Title(n.catalog),
+			"size":   "Large",
+			"weight": "Bolder",
+			"color":  "Attention",
+			"wrap":   true,
+		},
+	}
+	if alert.Type() != KindMassDisconnection {
+		// The other kinds show their own message, e.g. with the attempts of
+		// a brute-force alert. Teams needs blank lines to break the lines.
+		body = append(body, map[string]interface{}{
+			"type": "TextBlock",
+			"text": strings.ReplaceAll(alert.Message(n.catalog), "\n", "\n\n"),
+			"wrap": true,
+		})
+	} else {
+		body = append(body,
+			map[string]interface{}{
+				"type": "FactSet",
+				"facts": []map[string]string{
+					{"title": "📅 " + n.catalog.T("alert.time"), "value": alert.Timestamp},
+					{"title": "🔢 " + n.catalog.T("alert.total"), "value": strconv.Itoa(alert.TotalExtensions())},
+				},
+			},
+			map[string]interface{}{
+				"type":   "TextBlock",
+				"text":   "📋 " + n.catalog.T("alert.list"),
+				"weight": "Bolder",
+				"wrap":   true,
+			},
+			map[string]interface{}{
+				"type": "TextBlock",
+				"text": "- " + strings.Join(alert.Extensions, "\n- "),
+				"wrap": true,
+			})
+		if len(alert.Remediation) > 0 {
+			body = append(body,
+				map[string]interface{}{
+					"type":   "TextBlock",
+					"text":   "🛠 " + n.catalog.T("remediation.title"),
+					"weight": "Bolder",
+					"wrap":   true,
+				},
+				map[string]interface{}{
+					"type": "TextBlock",
+					"text": "- " + strings.ReplaceAll(alert.RemediationSummary(n.catalog), "\n", "\n- "),
+					"wrap": true,
+				})
+		}
+	}
+
+	// Adaptive Card with the same fields shown in Slack
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+
+	// Teams expects the card wrapped in a message attachment
+	data := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     card,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode Teams card: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", n.config.Teams.WebhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending message to Teams: %w", err)
+	}
+	return nil
+}
@@ -10,29 +10,39 @@ import (
 	"github.com/lordbasex/parsewatchdog/config"
 )
 
+// markdownEscaper escapes the characters of the Telegram legacy Markdown, so
+// names such as trunk_provider are not parsed as entities
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
 // TelegramNotifier manages Telegram notifications
 type TelegramNotifier struct {
-	config *config.Config
+	config  *config.Config
+	catalog Catalog
 }
 
 // NewTelegramNotifier initializes a TelegramNotifier
 func NewTelegramNotifier(cfg *config.Config) *TelegramNotifier {
-	return &TelegramNotifier{config: cfg}
+	return &TelegramNotifier{config: cfg, catalog: NewCatalog(cfg)}
 }
 
 // Send formats and sends a structured message to Telegram with emojis
-func (n *TelegramNotifier) Send(subject, message string) error {
-	// Parse message to extract timestamp, totalExtensions, and extensions
-	parsedData, err := parseTelegramMessage(message)
-	if err != nil {
-		return fmt.Errorf("error parsing message: %v", err)
-	}
-
+func (n *TelegramNotifier) Send(alert *Alert) error {
 	// Formatted message with emojis for Telegram
-	formattedMessage := fmt.Sprintf("🚨 *Mass Disconnection Alert* 🚨\n\n📅 *Time:* %s\n🔢 *Total Extensions Disconnected:* %s\n📋 *Extensions List:*\n%s",
-		parsedData["Timestamp"],
-		parsedData["TotalExtensions"],
-		parsedData["Extensions"])
+	// Every dynamic field is escaped, only the formatting is Markdown
+	esc := markdownEscaper.Replace
+	// The other kinds show their own message, e.g. with the attempts of a
+	// brute-force alert
+	formattedMessage := fmt.Sprintf("🚨 *%s* 🚨\n\n%s", esc(alert.Title(n.catalog)), esc(alert.Message(n.catalog)))
+	if alert.Type() == KindMassDisconnection {
+		formattedMessage = fmt.Sprintf("🚨 *%s* 🚨\n\n📅 *%s:* %s\n🔢 *%s:* %d\n📋 *%s:*\n%s",
+			esc(alert.Title(n.catalog)),
+			esc(n.catalog.T("alert.time")), esc(alert.Timestamp),
+			esc(n.catalog.T("alert.total")), alert.TotalExtensions(),
+			esc(n.catalog.T("alert.list")), esc(strings.Join(alert.Extensions, "\n- ")))
+		if len(alert.Remediation) > 0 {
+			formattedMessage += fmt.Sprintf("\n\n🛠 *%s:*\n%s", esc(n.catalog.T("remediation.title")), esc(alert.RemediationSummary(n.catalog)))
+		}
+	}
 
 	// Telegram API URL
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.config.Telegram.Token)
@@ -46,30 +56,14 @@ func (n *TelegramNotifier) Send(subject, message string) error {
 
 	// Convert data to JSON and send request
 	jsonData, _ := json.Marshal(data)
-	_, err = http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	return err
-}
-
-// parseTelegramMessage extracts timestamp, totalExtensions, and extensions for Telegram format
-func parseTelegramMessage(message string) (map[string]string, error) {
-	lines := strings.Split(message, "\n")
-	if len(lines) < 3 {
-		return nil, fmt.Errorf("message format is invalid")
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	// Extract fields assuming specific format
-	timestamp := strings.TrimSpace(strings.TrimPrefix(lines[0], "Mass disconnection detected at"))
-	totalExtensionsStr := strings.TrimSpace(strings.TrimPrefix(lines[1], "Total: "))
-	totalExtensions := strings.Split(totalExtensionsStr, " ")[0] // extract number only
-	extensions := strings.TrimSpace(strings.TrimPrefix(lines[2], "Extensions:"))
-
-	// Prepare formatted extensions list with line breaks for readability
-	formattedExtensions := strings.ReplaceAll(extensions, ", ", "\n- ")
-
-	// Prepare the data map for the template
-	return map[string]string{
-		"Timestamp":       timestamp,
-		"TotalExtensions": totalExtensions,
-		"Extensions":      formattedExtensions,
-	}, nil
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending message to Telegram: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/ami"
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// VoiceNotifier places a phone call through the local Asterisk, either by
+// dropping a call file in the spool directory or with an AMI Originate
+type VoiceNotifier struct {
+	config  *config.Config
+	catalog Catalog
+}
+
+// NewVoiceNotifier initializes a VoiceNotifier
+func NewVoiceNotifier(cfg *config.Config) *VoiceNotifier {
+	return &VoiceNotifier{config: cfg, catalog: NewCatalog(cfg)}
+}
+
+// Send calls every configured number. The call is connected to the
+// configured dialplan context, which receives the alert in PWD_* variables.
+func (n *VoiceNotifier) Send(alert *Alert) error {
+	if !alert.AtLeast(n.config.Voice.MinSeverity) {
+		return nil
+	}
+
+	var errs []string
+	for _, number := range n.config.Voice.Numbers {
+		var err error
+		if n.config.Voice.Method == "ami" {
+			err = n.originate(number, alert)
+		} else {
+			err = n.writeCallFile(number, alert)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", number, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error placing voice call: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// variables returns the channel variables available to the dialplan
+func (n *VoiceNotifier) variables(number string, alert *Alert) []string {
+	return []string{
+		"PWD_INCIDENT=" + alert.ID,
+		"PWD_NUMBER=" + number,
+		"PWD_HOST=" + alert.Host,
+		"PWD_COUNT=" + strconv.Itoa(alert.TotalExtensions()),
+		"PWD_SEVERITY=" + alert.Level(),
+		"PWD_MESSAGE=" + strings.ReplaceAll(alert.Subject(n.catalog), ",", " "),
+		"PWD_SOUND=" + n.config.Voice.Sound,
+	}
+}
+
+// writeCallFile creates the call file outside the spool and moves it in, so
+// Asterisk never reads a partially written file
+func (n *VoiceNotifier) writeCallFile(number string, alert *Alert) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Channel: %s\n", fmt.Sprintf(n.config.Voice.Channel, number))
+	fmt.Fprintf(&b, "CallerID: %s\n", n.config.Voice.CallerID)
+	fmt.Fprintf(&b, "MaxRetries: %d\n", n.config.Voice.MaxRetries)
+	fmt.Fprintf(&b, "RetryTime: %d\n", n.config.Voice.RetryTime)
+	fmt.Fprintf(&b, "WaitTime: %d\n", n.config.Voice.WaitTime)
+	fmt.Fprintf(&b, "Context: %s\n", n.config.Voice.Context)
+	fmt.Fprintf(&b, "Extension: %s\n", n.config.Voice.Extension)
+	fmt.Fprintf(&b, "Priority: %d\n", n.config.Voice.Priority)
+	for _, variable := range n.variables(number, alert) {
+		fmt.Fprintf(&b, "Setvar: %s\n", variable)
+	}
+	b.WriteString("Archive: yes\n")
+
+	tmp, err := os.CreateTemp(filepath.Dir(filepath.Clean(n.config.Voice.SpoolDir)), "parsewatchdog-*.call")
+	if err != nil {
+		return fmt.Errorf("failed to create call file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write call file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write call file: %w", err)
+	}
+
+	target := filepath.Join(n.config.Voice.SpoolDir, fmt.Sprintf("parsewatchdog-%s-%s.call", alert.ID, number))
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to move call file to spool: %w", err)
+	}
+	return nil
+}
+
+// originate places the call with an asynchronous AMI Originate action
+func (n *VoiceNotifier) originate(number string, alert *Alert) error {
+	client, err := ami.Dial(n.config.AMI.Address(), n.config.AMI.Username, n.config.AMI.Secret, 10*time.Second, "off")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Action("Originate", ami.Message{
+		"Channel":  fmt.Sprintf(n.config.Voice.Channel, number),
+		"CallerID": n.config.Voice.CallerID,
+		"Context":  n.config.Voice.Context,
+		"Exten":    n.config.Voice.Extension,
+		"Priority": strconv.Itoa(n.config.Voice.Priority),
+		"Timeout":  strconv.Itoa(n.config.Voice.WaitTime * 1000),
+		"Variable": strings.Join(n.variables(number, alert), ","),
+		"Async":    "true",
+	})
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(resp["Response"], "Success") {
+		return fmt.Errorf("originate failed: %s", resp["Message"])
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// WebhookNotifier sends the alert to an arbitrary HTTP endpoint using a
+// user defined body template
+type WebhookNotifier struct {
+	webhook config.WebhookConfig
+	catalog Catalog
+}
+
+// NewWebhookNotifier initializes a WebhookNotifier for one [webhook.<name>] section
+func NewWebhookNotifier(cfg *config.Config, webhook config.WebhookConfig) *WebhookNotifier {
+	return &WebhookNotifier{webhook: webhook, catalog: NewCatalog(cfg)}
+}
+
+// webhookFuncs are the helper functions available inside body templates
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": strings.Join,
+}
+
+// webhookTemplates are the body templates of the enabled webhooks, parsed
+// once by ValidateWebhooks
+var webhookTemplates = make(map[string]*template.Template)
+
+// ValidateWebhooks parses the body templates of the enabled webhooks and
+// renders them with a sample alert, so template errors are found at startup
+func ValidateWebhooks(cfg *config.Config) error {
+	sample := &Alert{ID: "sample", Host: "sample", Timestamp: "2006-01-02 15:04:05", Extensions: []string{"1001"}}
+	for _, webhook := range cfg.Webhooks {
+		if !webhook.Enabled {
+			continue
+		}
+		tmpl, err := template.New(webhook.Name).Funcs(webhookFuncs).Parse(webhook.Body)
+		if err != nil {
+			return fmt.Errorf("webhook %s: invalid body template: %w", webhook.Name, err)
+		}
+		webhookTemplates[webhook.Name] = tmpl
+		if _, err := NewWebhookNotifier(cfg, webhook).render(sample); err != nil {
+			return fmt.Errorf("webhook %s: %w", webhook.Name, err)
+		}
+	}
+	return nil
+}
+
+// render renders the body template with the alert
+func (n *WebhookNotifier) render(alert *Alert) (*bytes.Buffer, error) {
+	tmpl, ok := webhookTemplates[n.webhook.Name]
+	if !ok {
+		var err error
+		if tmpl, err = template.New(n.webhook.Name).Funcs(webhookFuncs).Parse(n.webhook.Body); err != nil {
+			return nil, fmt.Errorf("error parsing body template: %v", err)
+		}
+	}
+
+	// Data available to the template: {{.ID}}, {{.Kind}}, {{.Host}},
+	// {{.Severity}}, {{.Resolved}}, {{.Timestamp}}, {{.Tenant}}, {{.Group}},
+	// {{.Extensions}}, {{.TotalExtensions}}, {{.Subject}} and {{.Message}}
+	var body bytes.Buffer
+	err := tmpl.Execute(&body, map[string]interface{}{
+		"ID":              alert.ID,
+		"Kind":            alert.Type(),
+		"Host":            alert.Host,
+		"Severity":        alert.Level(),
+		"Resolved":        alert.Resolved,
+		"Timestamp":       alert.Timestamp,
+		"Tenant":          alert.Tenant,
+		"Group":           alert.Group,
+		"Extensions":      alert.Extensions,
+		"TotalExtensions": alert.TotalExtensions(),
+		"Subject":         alert.Subject(n.catalog),
+		"Message":         alert.Message(n.catalog),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error executing body template: %v", err)
+	}
+	return &body, nil
+}
+
+// Send renders the body template with the alert and sends the request
+func (n *WebhookNotifier) Send(alert *Alert) error {
+	body, err := n.render(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(n.webhook.Method, n.webhook.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", n.webhook.ContentType)
+	for name, value := range n.webhook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if err := doRequest(req); err != nil {
+		return fmt.Errorf("error sending webhook %s: %w", n.webhook.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package remediation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/ami"
+	"github.com/lordbasex/parsewatchdog/config"
+	"github.com/lordbasex/parsewatchdog/notification"
+)
+
+// Remediator runs the configured remediation actions, enforcing the cooldown
+// between runs and the maximum number of attempts per day
+type Remediator struct {
+	config   *config.Config
+	lastRun  time.Time
+	day      string
+	attempts int
+}
+
+// New initializes a Remediator
+func New(cfg *config.Config) *Remediator {
+	return &Remediator{config: cfg}
+}
+
+// Allowed reports whether a new attempt can run now, or why it cannot
+func (r *Remediator) Allowed(now time.Time) error {
+	if day := now.Format("2006-01-02"); day != r.day {
+		r.day = day
+		r.attempts = 0
+	}
+	if r.attempts >= r.config.Remediation.MaxPerDay {
+		return fmt.Errorf("daily limit of %d attempts reached", r.config.Remediation.MaxPerDay)
+	}
+	cooldown := time.Duration(r.config.Remediation.Cooldown) * time.Second
+	if !r.lastRun.IsZero() && now.Sub(r.lastRun) < cooldown {
+		return fmt.Errorf("cooldown active until %s", r.lastRun.Add(cooldown).Format("15:04:05"))
+	}
+	return nil
+}
+
+// Run executes every action in order and records the attempt
+func (r *Remediator) Run(now time.Time) []notification.ActionResult {
+	r.lastRun = now
+	r.attempts++
+
+	results := make([]notification.ActionResult, 0, len(r.config.Remediation.Actions))
+	for _, action := range r.config.Remediation.Actions {
+		var output string
+		var err error
+		if action.Type == "ami" {
+			output, err = runAMI(r.config, action)
+		} else {
+			output, err = runShell(action)
+		}
+
+		result := notification.ActionResult{Name: action.Name, Success: err == nil, Output: output}
+		if err != nil {
+			result.Output = strings.TrimSpace(output + "\n" + err.Error())
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// runAMI executes an Asterisk CLI command through the AMI Command action
+func runAMI(cfg *config.Config, action config.RemediationAction) (string, error) {
+	client, err := ami.Dial(cfg.AMI.Address(), cfg.AMI.Username, cfg.AMI.Secret, time.Duration(action.Timeout)*time.Second, "off")
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	resp, err := client.Action("Command", ami.Message{"Command": action.Command})
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(resp["Response"], "Success") && !strings.EqualFold(resp["Response"], "Follows") {
+		return resp["Output"], fmt.Errorf("command failed: %s", resp["Message"])
+	}
+	return resp["Output"], nil
+}
+
+// runShell executes a shell command with the action timeout
+func runShell(action config.RemediationAction) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(action.Timeout)*time.Second)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", action.Command)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %ds", action.Timeout)
+	}
+	return strings.TrimSpace(output.String()), err
+}
@@ -0,0 +1,206 @@
+package security
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// Tracker counts the failed authentication attempts of every source IP over
+// the window of the rule that reported them and keeps the list of blocked
+// IPs, written to the blocklist file for the firewall
+type Tracker struct {
+	config   *config.Config
+	ignore   []*net.IPNet
+	attempts map[string]*history
+	blocked  map[string]time.Time
+}
+
+// history holds the recent attempts of a source IP
+type history struct {
+	times  []time.Time
+	window time.Duration
+}
+
+// New initializes a Tracker, validating the ignored addresses
+func New(cfg *config.Config) (*Tracker, error) {
+	t := &Tracker{
+		config:   cfg,
+		attempts: make(map[string]*history),
+		blocked:  make(map[string]time.Time),
+	}
+	for _, entry := range cfg.Security.Ignore {
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore address %q: %w", entry, err)
+		}
+		t.ignore = append(t.ignore, network)
+	}
+	return t, nil
+}
+
+// Record adds a failed attempt of ip at the given time. It returns the
+// attempts within window and whether ip has just been blocked after reaching
+// threshold, which happens once until its block expires.
+func (t *Tracker) Record(ip string, at time.Time, threshold int, window time.Duration) (int, bool) {
+	if t.ignored(ip) {
+		return 0, false
+	}
+
+	h, ok := t.attempts[ip]
+	if !ok {
+		h = &history{}
+		t.attempts[ip] = h
+	}
+	h.window = window
+	recent := h.times[:0]
+	for _, attempt := range h.times {
+		if at.Sub(attempt) < window {
+			recent = append(recent, attempt)
+		}
+	}
+	h.times = append(recent, at)
+
+	attempts := len(h.times)
+	if attempts < threshold {
+		return attempts, false
+	}
+	if _, ok := t.blocked[ip]; ok {
+		return attempts, false
+	}
+	t.blocked[ip] = time.Now().Add(time.Duration(t.config.Security.BlockTime) * time.Second)
+	return attempts, true
+}
+
+// Expire removes the blocks that expired and the attempts out of the window
+// and returns the IPs unblocked
+func (t *Tracker) Expire(now time.Time) []string {
+	var expired []string
+	for ip, until := range t.blocked {
+		if now.After(until) {
+			delete(t.blocked, ip)
+			expired = append(expired, ip)
+		}
+	}
+
+	for ip, h := range t.attempts {
+		if now.Sub(h.times[len(h.times)-1]) >= h.window {
+			delete(t.attempts, ip)
+		}
+	}
+	return expired
+}
+
+// Blocked returns the blocked IPs sorted
+func (t *Tracker) Blocked() []string {
+	ips := make([]string, 0, len(t.blocked))
+	for ip := range t.blocked {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// LoadBlocklist restores the blocks of the blocklist file written by a
+// previous run. The file keeps no expiry times, so the restored blocks expire
+// block_time seconds after its last change. It reports whether the file holds
+// addresses that are no longer blocked, i.e. expired, ignored or invalid, and
+// must be written again.
+func (t *Tracker) LoadBlocklist(now time.Time) (bool, error) {
+	path := t.config.Security.BlocklistFile
+	if path == "" {
+		return false, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	until := info.ModTime().Add(time.Duration(t.config.Security.BlockTime) * time.Second)
+
+	stale := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		ip := strings.TrimSpace(scanner.Text())
+		if ip == "" {
+			continue
+		}
+		if net.ParseIP(strings.Trim(ip, "[]")) == nil || t.ignored(ip) || !now.Before(until) {
+			stale = true
+			continue
+		}
+		t.blocked[ip] = until
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	return stale, nil
+}
+
+// WriteBlocklist replaces the blocklist file with the blocked IPs, one per
+// line. The file is written next to the target and renamed so readers never
+// see it half written.
+func (t *Tracker) WriteBlocklist() error {
+	path := t.config.Security.BlocklistFile
+	if path == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blocklist-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blocklist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, ip := range t.Blocked() {
+		fmt.Fprintln(tmp, ip)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace blocklist: %w", err)
+	}
+	return nil
+}
+
+// ignored reports whether ip is in the ignore list
+func (t *Tracker) ignored(ip string) bool {
+	addr := net.ParseIP(strings.Trim(ip, "[]"))
+	if addr == nil {
+		return false
+	}
+	for _, network := range t.ignore {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
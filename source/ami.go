@@ -0,0 +1,153 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/ami"
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// Reconnection delays of the network sources, doubled after every failed attempt
+const (
+	reconnectMinBackoff = 1 * time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// amiPingInterval is how often the AMI connection is checked with a Ping,
+// shortened by the tests
+var amiPingInterval = 30 * time.Second
+
+// AMISource reads PeerStatus, ContactStatus and Registry events from the Asterisk
+// Manager Interface
+type AMISource struct {
+	config *config.Config
+}
+
+// NewAMISource initializes an AMISource using the [ami] section
+func NewAMISource(cfg *config.Config) *AMISource {
+	return &AMISource{config: cfg}
+}
+
+// Run connects to the AMI and reconnects whenever the connection is lost
+func (s *AMISource) Run(events chan<- Event) {
+	backoff := reconnectMinBackoff
+	for {
+		client, err := ami.Dial(s.config.AMI.Address(), s.config.AMI.Username, s.config.AMI.Secret, 10*time.Second, "system,user")
+		if err != nil {
+			logMessage(s.config, 1, fmt.Sprintf("AMI source: %v, retrying in %s", err, backoff))
+			time.Sleep(backoff)
+			backoff = min(backoff*2, reconnectMaxBackoff)
+			continue
+		}
+
+		logMessage(s.config, 1, fmt.Sprintf("AMI source connected to %s", s.config.AMI.Address()))
+		backoff = reconnectMinBackoff
+		s.consume(client, events)
+		client.Close()
+		logMessage(s.config, 1, fmt.Sprintf("AMI source disconnected: %v", client.Err()))
+	}
+}
+
+// consume forwards the endpoint state events until the connection is lost.
+// A periodic Ping detects half-open connections.
+func (s *AMISource) consume(client *ami.Client, events chan<- Event) {
+	ping := time.NewTicker(amiPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-client.Events():
+			if event, ok := parseAMIEvent(msg); ok {
+				logMessage(s.config, 2, fmt.Sprintf("AMI event: %s %s is now %s", msg["Event"], event.Entity, event.State))
+				events <- event
+			}
+		case <-ping.C:
+			if _, err := client.Action("Ping", nil); err != nil {
+				return
+			}
+		case <-client.Done():
+			return
+		}
+	}
+}
+
+// parseAMIEvent converts PeerStatus, ContactStatus and Registry events, and
+// the acknowledgement user events, to events
+func parseAMIEvent(msg ami.Message) (Event, bool) {
+	var entity, status string
+	var rtt time.Duration
+	kind := KindEndpointState
+	switch msg["Event"] {
+	case "PeerStatus":
+		// Peer: PJSIP/1001 or SIP/1001
+		entity = msg["Peer"]
+		if _, name, ok := strings.Cut(entity, "/"); ok {
+			entity = name
+		}
+		status = msg["PeerStatus"]
+		if status == "Lagged" {
+			status = StateReachable
+		}
+		// Time is the qualify RTT in milliseconds
+		if value, err := strconv.Atoi(msg["Time"]); err == nil && value > 0 {
+			rtt = time.Duration(value) * time.Millisecond
+		}
+	case "ContactStatus":
+		entity = msg["EndpointName"]
+		if entity == "" {
+			entity = msg["AOR"]
+		}
+		status = msg["ContactStatus"]
+		if value, err := strconv.Atoi(msg["RoundtripUsec"]); err == nil && value > 0 {
+			rtt = time.Duration(value) * time.Microsecond
+		}
+	case "Registry":
+		// Outbound registrations: Username is the client URI
+		kind = KindRegistration
+		entity = msg["Username"]
+		if entity == "" {
+			entity = msg["Domain"]
+		}
+		switch msg["Status"] {
+		case "Registered":
+			status = StateReachable
+		case "Rejected", "Failed":
+			status = StateUnreachable
+		}
+	case "UserEvent":
+		// Acknowledgement from the voice call dialplan
+		if msg["UserEvent"] != ackUserEvent || msg["Incident"] == "" {
+			return Event{}, false
+		}
+		return Event{Timestamp: amiTimestamp(msg).Format(TimestampLayout), Entity: msg["Incident"], State: StateAcknowledged, Detail: msg["Number"]}, true
+	default:
+		return Event{}, false
+	}
+
+	var state string
+	switch {
+	case strings.EqualFold(status, StateUnreachable):
+		state = StateUnreachable
+	case strings.EqualFold(status, StateReachable):
+		state = StateReachable
+	default:
+		return Event{}, false
+	}
+	if entity == "" {
+		return Event{}, false
+	}
+
+	return Event{Timestamp: amiTimestamp(msg).Format(TimestampLayout), Entity: entity, State: state, Detail: msg["Cause"], Kind: kind, RTT: rtt}, true
+}
+
+// amiTimestamp returns the event time, from the Timestamp field when
+// timestampevents is enabled in manager.conf, or the current time otherwise
+func amiTimestamp(msg ami.Message) time.Time {
+	if value, err := strconv.ParseFloat(msg["Timestamp"], 64); err == nil {
+		return time.Unix(int64(value), 0)
+	}
+	return time.Now()
+}
@@ -0,0 +1,355 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/ami"
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// fakeAMI is a minimal Asterisk Manager Interface server. It accepts the
+// configured secret, answers Ping actions and hands every logged in
+// connection to the test.
+type fakeAMI struct {
+	listener net.Listener
+	secret   string
+
+	mu         sync.Mutex
+	failLogins int
+	pings      int
+
+	logins chan *fakeAMIConn
+}
+
+// fakeAMIConn is a logged in connection of the fake AMI
+type fakeAMIConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+}
+
+func newFakeAMI(t *testing.T, secret string) *fakeAMI {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeAMI{listener: listener, secret: secret, logins: make(chan *fakeAMIConn, 10)}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+// config returns a configuration pointing to the fake AMI
+func (f *fakeAMI) config(secret string) *config.Config {
+	addr := f.listener.Addr().(*net.TCPAddr)
+	cfg := &config.Config{}
+	cfg.AMI = config.AMIConfig{Host: addr.IP.String(), Port: addr.Port, Username: "parsewatchdog", Secret: secret}
+	return cfg
+}
+
+// failNextLogins makes the next n logins fail, as if the credentials were
+// rejected while Asterisk restarts
+func (f *fakeAMI) failNextLogins(n int) {
+	f.mu.Lock()
+	f.failLogins = n
+	f.mu.Unlock()
+}
+
+// pingCount returns the number of Ping actions received
+func (f *fakeAMI) pingCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pings
+}
+
+func (f *fakeAMI) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(&fakeAMIConn{conn: conn})
+	}
+}
+
+// handle answers the actions of a connection until it is closed
+func (f *fakeAMI) handle(c *fakeAMIConn) {
+	defer c.conn.Close()
+	c.write("Asterisk Call Manager/5.0.1\r\n")
+
+	reader := textproto.NewReader(bufio.NewReader(c.conn))
+	for {
+		header, err := reader.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		id := header.Get("ActionID")
+		switch header.Get("Action") {
+		case "Login":
+			f.mu.Lock()
+			fail := f.failLogins > 0 || header.Get("Secret") != f.secret
+			if f.failLogins > 0 {
+				f.failLogins--
+			}
+			f.mu.Unlock()
+			if fail {
+				c.send(ami.Message{"Response": "Error", "ActionID": id, "Message": "Authentication failed"})
+				return
+			}
+			c.send(ami.Message{"Response": "Success", "ActionID": id, "Message": "Authentication accepted"})
+			f.logins <- c
+		case "Ping":
+			f.mu.Lock()
+			f.pings++
+			f.mu.Unlock()
+			c.send(ami.Message{"Response": "Success", "ActionID": id, "Ping": "Pong"})
+		default:
+			c.send(ami.Message{"Response": "Success", "ActionID": id})
+		}
+	}
+}
+
+func (c *fakeAMIConn) write(data string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.Write([]byte(data))
+}
+
+// send writes a response or event
+func (c *fakeAMIConn) send(msg ami.Message) {
+	var b strings.Builder
+	for key, value := range msg {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	b.WriteString("\r\n")
+	c.write(b.String())
+}
+
+// waitLogin returns the next logged in connection
+func (f *fakeAMI) waitLogin(t *testing.T, timeout time.Duration) *fakeAMIConn {
+	t.Helper()
+	select {
+	case c := <-f.logins:
+		return c
+	case <-time.After(timeout):
+		t.Fatalf("no AMI login within %s", timeout)
+		return nil
+	}
+}
+
+// waitEvent returns the next event sent by the source
+func waitEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event received")
+		return Event{}
+	}
+}
+
+func TestAMILogin(t *testing.T) {
+	f := newFakeAMI(t, "secret")
+
+	cfg := f.config("secret")
+	client, err := ami.Dial(cfg.AMI.Address(), cfg.AMI.Username, cfg.AMI.Secret, 5*time.Second, "system")
+	if err != nil {
+		t.Fatalf("login with the right secret failed: %v", err)
+	}
+	client.Close()
+
+	cfg = f.config("wrong")
+	_, err = ami.Dial(cfg.AMI.Address(), cfg.AMI.Username, cfg.AMI.Secret, 5*time.Second, "system")
+	if err == nil || !strings.Contains(err.Error(), "Authentication failed") {
+		t.Fatalf("login with a wrong secret: err = %v, want authentication failure", err)
+	}
+}
+
+func TestParseAMIEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  ami.Message
+		want Event
+		ok   bool
+	}{
+		{
+			name: "chan_sip peer unreachable",
+			msg:  ami.Message{"Event": "PeerStatus", "Peer": "SIP/1001", "PeerStatus": "Unreachable", "Timestamp": "1730639106.123"},
+			want: Event{Entity: "1001", State: StateUnreachable, Kind: KindEndpointState},
+			ok:   true,
+		},
+		{
+			name: "lagged peer with RTT",
+			msg:  ami.Message{"Event": "PeerStatus", "Peer": "SIP/1002", "PeerStatus": "Lagged", "Time": "2500"},
+			want: Event{Entity: "1002", State: StateReachable, Kind: KindEndpointState, RTT: 2500 * time.Millisecond},
+			ok:   true,
+		},
+		{
+			name: "pjsip contact reachable",
+			msg:  ami.Message{"Event": "ContactStatus", "EndpointName": "acme-1001", "ContactStatus": "Reachable", "RoundtripUsec": "23456"},
+			want: Event{Entity: "acme-1001", State: StateReachable, Kind: KindEndpointState, RTT: 23456 * time.Microsecond},
+			ok:   true,
+		},
+		{
+			name: "contact without endpoint uses the AOR",
+			msg:  ami.Message{"Event": "ContactStatus", "AOR": "1003", "ContactStatus": "Unreachable"},
+			want: Event{Entity: "1003", State: StateUnreachable, Kind: KindEndpointState},
+			ok:   true,
+		},
+		{
+			name: "registration rejected",
+			msg:  ami.Message{"Event": "Registry", "Username": "sip:pbx@sip.provider.com", "Status": "Rejected", "Cause": "403"},
+			want: Event{Entity: "sip:pbx@sip.provider.com", State: StateUnreachable, Kind: KindRegistration, Detail: "403"},
+			ok:   true,
+		},
+		{
+			name: "registration registered",
+			msg:  ami.Message{"Event": "Registry", "Username": "sip:pbx@sip.provider.com", "Status": "Registered"},
+			want: Event{Entity: "sip:pbx@sip.provider.com", State: StateReachable, Kind: KindRegistration},
+			ok:   true,
+		},
+		{
+			name: "voice call acknowledgement",
+			msg:  ami.Message{"Event": "UserEvent", "UserEvent": "ParseWatchdogAck", "Incident": "pbx-20241103T130506", "Number": "+5491100000000"},
+			want: Event{Entity: "pbx-20241103T130506", State: StateAcknowledged, Detail: "+5491100000000"},
+			ok:   true,
+		},
+		{
+			name: "other user events are ignored",
+			msg:  ami.Message{"Event": "UserEvent", "UserEvent": "Other", "Incident": "pbx-20241103T130506"},
+		},
+		{
+			name: "contact created is ignored",
+			msg:  ami.Message{"Event": "ContactStatus", "EndpointName": "1001", "ContactStatus": "Created"},
+		},
+		{
+			name: "other events are ignored",
+			msg:  ami.Message{"Event": "FullyBooted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAMIEvent(tt.msg)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if got.Timestamp == "" {
+				t.Errorf("missing timestamp")
+			}
+			got.Timestamp = ""
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// The Timestamp field is used when timestampevents is enabled
+	event, _ := parseAMIEvent(ami.Message{"Event": "PeerStatus", "Peer": "SIP/1001", "PeerStatus": "Reachable", "Timestamp": "1730639106.123"})
+	if want := time.Unix(1730639106, 0).Format(TimestampLayout); event.Timestamp != want {
+		t.Errorf("timestamp = %q, want %q", event.Timestamp, want)
+	}
+}
+
+func TestAMISourceEvents(t *testing.T) {
+	f := newFakeAMI(t, "secret")
+	events := make(chan Event, 10)
+	go NewAMISource(f.config("secret")).Run(events)
+
+	conn := f.waitLogin(t, 5*time.Second)
+	conn.send(ami.Message{"Event": "PeerStatus", "Peer": "SIP/1001", "PeerStatus": "Unreachable"})
+	conn.send(ami.Message{"Event": "ContactStatus", "EndpointName": "1002", "ContactStatus": "Reachable", "RoundtripUsec": "1000"})
+	conn.send(ami.Message{"Event": "Registry", "Username": "sip:pbx@sip.provider.com", "Status": "Failed"})
+
+	if event := waitEvent(t, events); event.Entity != "1001" || event.State != StateUnreachable {
+		t.Errorf("PeerStatus event = %+v", event)
+	}
+	if event := waitEvent(t, events); event.Entity != "1002" || event.State != StateReachable || event.RTT != time.Millisecond {
+		t.Errorf("ContactStatus event = %+v", event)
+	}
+	if event := waitEvent(t, events); event.Kind != KindRegistration || event.State != StateUnreachable {
+		t.Errorf("Registry event = %+v", event)
+	}
+}
+
+func TestAMISourcePing(t *testing.T) {
+	defer func(interval time.Duration) { amiPingInterval = interval }(amiPingInterval)
+	amiPingInterval = 20 * time.Millisecond
+
+	f := newFakeAMI(t, "secret")
+	go NewAMISource(f.config("secret")).Run(make(chan Event, 10))
+	f.waitLogin(t, 5*time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for f.pingCount() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d pings, want at least 3", f.pingCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAMISourceReconnect(t *testing.T) {
+	f := newFakeAMI(t, "secret")
+	events := make(chan Event, 10)
+	go NewAMISource(f.config("secret")).Run(events)
+
+	conn := f.waitLogin(t, 5*time.Second)
+
+	// The connection drops and the first login after it fails, so the
+	// source must wait for the backoff before the next attempt
+	f.failNextLogins(1)
+	dropped := time.Now()
+	conn.conn.Close()
+
+	conn = f.waitLogin(t, 5*time.Second)
+	if elapsed := time.Since(dropped); elapsed < reconnectMinBackoff {
+		t.Errorf("reconnected after %s, want a backoff of at least %s", elapsed, reconnectMinBackoff)
+	}
+
+	conn.send(ami.Message{"Event": "PeerStatus", "Peer": "PJSIP/1001", "PeerStatus": "Reachable"})
+	if event := waitEvent(t, events); event.Entity != "1001" || event.State != StateReachable {
+		t.Errorf("event after reconnecting = %+v", event)
+	}
+}
+
+// List collects the events of a list action, as used by the endpoint
+// inventory
+func TestAMIList(t *testing.T) {
+	f := newFakeAMI(t, "secret")
+	cfg := f.config("secret")
+	client, err := ami.Dial(cfg.AMI.Address(), cfg.AMI.Username, cfg.AMI.Secret, 5*time.Second, "off")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	defer client.Close()
+	conn := f.waitLogin(t, 5*time.Second)
+
+	// The list events carry the ID of the second action, Login being the first
+	id := "2"
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		for _, name := range []string{"1001", "1002"} {
+			conn.send(ami.Message{"Event": "EndpointList", "ActionID": id, "ObjectName": name})
+		}
+		conn.send(ami.Message{"Event": "EndpointListComplete", "ActionID": id, "EventList": "Complete"})
+	}()
+	list, err := client.List("PJSIPShowEndpoints", nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0]["ObjectName"] != "1001" || list[1]["ObjectName"] != "1002" {
+		t.Errorf("list = %v", list)
+	}
+}
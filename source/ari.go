@@ -0,0 +1,192 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// ariTimestampLayout is the layout of the timestamp field of ARI events
+const ariTimestampLayout = "2006-01-02T15:04:05.000-0700"
+
+// ariPingInterval is how often the WebSocket is checked with a ping
+const ariPingInterval = 30 * time.Second
+
+// ariEvent holds the fields used from EndpointStateChange,
+// ContactStatusChange and ChannelUserevent events
+type ariEvent struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Endpoint  struct {
+		Technology string `json:"technology"`
+		Resource   string `json:"resource"`
+		State      string `json:"state"`
+	} `json:"endpoint"`
+	ContactInfo struct {
+		URI           string `json:"uri"`
+		ContactStatus string `json:"contact_status"`
+		AOR           string `json:"aor"`
+		RoundtripUsec string `json:"roundtrip_usec"`
+	} `json:"contact_info"`
+	EventName string                 `json:"eventname"`
+	UserEvent map[string]interface{} `json:"userevent"`
+}
+
+// ARISource reads endpoint events from the Asterisk REST Interface WebSocket
+type ARISource struct {
+	config *config.Config
+}
+
+// NewARISource initializes an ARISource using the [ari] section
+func NewARISource(cfg *config.Config) *ARISource {
+	return &ARISource{config: cfg}
+}
+
+// Run connects to the ARI WebSocket and reconnects whenever it is lost
+func (s *ARISource) Run(events chan<- Event) {
+	backoff := reconnectMinBackoff
+	for {
+		conn, err := s.dial()
+		if err != nil {
+			logMessage(s.config, 1, fmt.Sprintf("ARI source: %v, retrying in %s", err, backoff))
+			time.Sleep(backoff)
+			backoff = min(backoff*2, reconnectMaxBackoff)
+			continue
+		}
+
+		logMessage(s.config, 1, fmt.Sprintf("ARI source connected to %s", s.config.ARI.URL))
+		backoff = reconnectMinBackoff
+		err = s.consume(conn, events)
+		conn.Close()
+		logMessage(s.config, 1, fmt.Sprintf("ARI source disconnected: %v", err))
+	}
+}
+
+// dial opens the events WebSocket subscribed to all event sources, which is
+// needed to receive the endpoint events
+func (s *ARISource) dial() (*websocket.Conn, error) {
+	base, err := url.Parse(s.config.ARI.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARI URL: %w", err)
+	}
+	switch base.Scheme {
+	case "https":
+		base.Scheme = "wss"
+	default:
+		base.Scheme = "ws"
+	}
+	base.Path = strings.TrimRight(base.Path, "/") + "/ari/events"
+	base.RawQuery = url.Values{
+		"app":          {s.config.ARI.App},
+		"api_key":      {s.config.ARI.Username + ":" + s.config.ARI.Password},
+		"subscribeAll": {"true"},
+	}.Encode()
+
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	conn, _, err := dialer.Dial(base.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ARI: %w", err)
+	}
+	return conn, nil
+}
+
+// consume forwards the endpoint events until the connection fails
+func (s *ARISource) consume(conn *websocket.Conn, events chan<- Event) error {
+	conn.SetReadDeadline(time.Now().Add(2 * ariPingInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * ariPingInterval))
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(ariPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var msg ariEvent
+		if err := json.Unmarshal(data, &msg); err != nil {
+			logMessage(s.config, 2, fmt.Sprintf("ARI source: invalid event: %v", err))
+			continue
+		}
+		if event, ok := parseARIEvent(msg); ok {
+			logMessage(s.config, 2, fmt.Sprintf("ARI event: %s %s is now %s", msg.Type, event.Entity, event.State))
+			events <- event
+		}
+	}
+}
+
+// parseARIEvent converts EndpointStateChange and ContactStatusChange events,
+// and the acknowledgement user events
+func parseARIEvent(msg ariEvent) (Event, bool) {
+	var state string
+	var rtt time.Duration
+	entity := msg.Endpoint.Resource
+	timestamp, err := time.Parse(ariTimestampLayout, msg.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+
+	switch msg.Type {
+	case "ChannelUserevent":
+		// Acknowledgement from the voice call dialplan
+		incident, _ := msg.UserEvent["Incident"].(string)
+		if msg.EventName != ackUserEvent || incident == "" {
+			return Event{}, false
+		}
+		number, _ := msg.UserEvent["Number"].(string)
+		return Event{Timestamp: timestamp.Local().Format(TimestampLayout), Entity: incident, State: StateAcknowledged, Detail: number}, true
+	case "EndpointStateChange":
+		switch msg.Endpoint.State {
+		case "offline":
+			state = StateUnreachable
+		case "online":
+			state = StateReachable
+		default:
+			return Event{}, false
+		}
+	case "ContactStatusChange":
+		switch {
+		case strings.EqualFold(msg.ContactInfo.ContactStatus, StateUnreachable):
+			state = StateUnreachable
+		case strings.EqualFold(msg.ContactInfo.ContactStatus, StateReachable):
+			state = StateReachable
+		default:
+			return Event{}, false
+		}
+		if entity == "" {
+			entity = msg.ContactInfo.AOR
+		}
+		if value, err := strconv.Atoi(msg.ContactInfo.RoundtripUsec); err == nil && value > 0 {
+			rtt = time.Duration(value) * time.Microsecond
+		}
+	default:
+		return Event{}, false
+	}
+	if entity == "" {
+		return Event{}, false
+	}
+	return Event{Timestamp: timestamp.Local().Format(TimestampLayout), Entity: entity, State: state, Kind: KindEndpointState, RTT: rtt}, true
+}
@@ -0,0 +1,63 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// FileSource tails the Asterisk full log
+type FileSource struct {
+	config *config.Config
+	parser *Parser
+	file   *os.File
+}
+
+// NewFileSource opens the configured log file positioned at its end
+func NewFileSource(cfg *config.Config, parser *Parser) (*FileSource, error) {
+	file, err := os.Open(cfg.Input.LogFile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file: %w", err)
+	}
+
+	// Start from the end of the file
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error seeking log file: %w", err)
+	}
+	return &FileSource{config: cfg, parser: parser, file: file}, nil
+}
+
+// Run reads the lines appended to the log file at regular intervals
+func (s *FileSource) Run(events chan<- Event) {
+	defer s.file.Close()
+
+	for {
+		s.read(events)
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// read sends the events of the lines appended since the last read
+func (s *FileSource) read(events chan<- Event) {
+	scanner := bufio.NewScanner(s.file)
+
+	logMessage(s.config, 2, "Incremental log file reading...")
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if event, ok := s.parser.Parse(line, time.Now()); ok {
+			logMessage(s.config, 2, fmt.Sprintf("Reading log line: %s", line))
+			events <- event
+		}
+	}
+
+	// Check for scanner errors
+	if err := scanner.Err(); err != nil {
+		logMessage(s.config, 2, fmt.Sprintf("Error reading log lines: %v", err))
+	}
+}
@@ -0,0 +1,130 @@
+package source
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// journalEntry holds the fields used from the journalctl JSON output. The
+// message is kept raw because journald encodes non UTF-8 messages as arrays
+// of bytes.
+type journalEntry struct {
+	Message           json.RawMessage `json:"MESSAGE"`
+	RealtimeTimestamp string          `json:"__REALTIME_TIMESTAMP"`
+}
+
+// JournaldSource follows the systemd journal through journalctl, filtered by
+// the configured units and syslog identifiers
+type JournaldSource struct {
+	config *config.Config
+	parser *Parser
+	path   string
+}
+
+// NewJournaldSource checks that journalctl is available
+func NewJournaldSource(cfg *config.Config, parser *Parser) (*JournaldSource, error) {
+	path, err := exec.LookPath("journalctl")
+	if err != nil {
+		return nil, fmt.Errorf("journalctl not found: %w", err)
+	}
+	return &JournaldSource{config: cfg, parser: parser, path: path}, nil
+}
+
+// Run follows the journal and restarts journalctl whenever it exits
+func (s *JournaldSource) Run(events chan<- Event) {
+	backoff := reconnectMinBackoff
+	for {
+		started := time.Now()
+		err := s.follow(events)
+		if time.Since(started) > reconnectMaxBackoff {
+			backoff = reconnectMinBackoff
+		}
+		logMessage(s.config, 1, fmt.Sprintf("Journald source: %v, restarting in %s", err, backoff))
+		time.Sleep(backoff)
+		backoff = min(backoff*2, reconnectMaxBackoff)
+	}
+}
+
+// args builds the journalctl arguments, starting at the end of the journal
+func (s *JournaldSource) args() []string {
+	args := []string{"--follow", "--lines=0", "--output=json"}
+	for _, unit := range s.config.Input.JournalUnits {
+		args = append(args, "--unit="+unit)
+	}
+	for _, identifier := range s.config.Input.JournalIdentifiers {
+		args = append(args, "--identifier="+identifier)
+	}
+	return args
+}
+
+// follow runs journalctl and sends the events of its entries until it exits
+func (s *JournaldSource) follow(events chan<- Event) error {
+	cmd := exec.Command(s.path, s.args()...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read journalctl output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start journalctl: %w", err)
+	}
+	logMessage(s.config, 1, fmt.Sprintf("Journald source following %v", s.args()))
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			logMessage(s.config, 2, fmt.Sprintf("Journald source: invalid entry: %v", err))
+			continue
+		}
+		message, ok := entry.message()
+		if !ok {
+			continue
+		}
+		if event, ok := s.parser.Parse(message, entry.time()); ok {
+			logMessage(s.config, 2, fmt.Sprintf("Reading journal entry: %s", message))
+			events <- event
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("failed to read journal entries: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("journalctl exited: %w", err)
+	}
+	return fmt.Errorf("journalctl exited")
+}
+
+// message decodes MESSAGE whether it is a string or an array of bytes
+func (e journalEntry) message() (string, bool) {
+	var text string
+	if err := json.Unmarshal(e.Message, &text); err == nil {
+		return text, true
+	}
+	var raw []byte
+	var bytes []int
+	if err := json.Unmarshal(e.Message, &bytes); err != nil {
+		return "", false
+	}
+	for _, b := range bytes {
+		raw = append(raw, byte(b))
+	}
+	return string(raw), true
+}
+
+// time returns the time the entry was received by journald
+func (e journalEntry) time() time.Time {
+	usec, err := strconv.ParseInt(e.RealtimeTimestamp, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMicro(usec)
+}
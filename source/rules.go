@@ -0,0 +1,337 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// Event kinds produced by the rules
+const (
+	// KindEndpointState events feed the mass disconnection detector
+	KindEndpointState = "endpoint_state"
+	// KindRegistration events report the outbound registration state of
+	// the trunks
+	KindRegistration = "registration"
+	// KindAuthFailure events are failed authentication attempts, with the
+	// source IP as entity
+	KindAuthFailure = "auth_failure"
+)
+
+// defaultRule matches the chan_sip (Peer) and pjsip (Endpoint and Contact)
+// state changes, considering case sensitivity for UNREACHABLE. Endpoint names
+// may be alphanumeric, e.g. acme-1001 or 1001@tenant, and contacts such as
+// 1001/sip:1001@10.0.0.5:5060 are reported by their AOR. The qualify RTT is
+// captured from "RTT: 23.456 msec" (pjsip) and "(23ms / 2000ms)" (chan_sip),
+// where Lagged peers are still reachable. The full log timestamp is optional
+// so the same rule works for syslog and journal messages.
+var defaultRule = config.RuleConfig{
+	Name:    "endpoint_state",
+	Pattern: `(?:^\[(?P<timestamp>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\] )?.*(?:Endpoint|Peer|Contact) ['"]?(?P<entity>[^\s'"/]+)\S* is now (?P<state>Unreachable|UNREACHABLE|Reachable|REACHABLE|Lagged|LAGGED)(?:.*?(?:RTT: (?P<rtt>[\d.]+) ?msec|\((?P<rtt>[\d.]+) ?ms / [\d.]+ ?ms\)))?`,
+	Kind:    KindEndpointState,
+}
+
+// registrationRule matches the outbound registration failures of pjsip and
+// chan_sip, used when trunks are configured. detail holds the SIP response.
+var registrationRule = config.RuleConfig{
+	Name:    "registration",
+	Pattern: `(?:^\[(?P<timestamp>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\] )?.*(?:(?:response '(?P<detail>\d+)'|No response) received from '[^']+' on registration attempt to '(?P<entity>[^']+)'|Registration for '(?P<entity>[^']+)' timed out)`,
+	Kind:    KindRegistration,
+	State:   StateUnreachable,
+}
+
+// registeredRule matches the successful outbound registrations of pjsip,
+// logged at debug level 1, used with registrationRule. chan_sip does not log
+// them, its recoveries come from the AMI Registry events.
+var registeredRule = config.RuleConfig{
+	Name:    "registered",
+	Pattern: `(?:^\[(?P<timestamp>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\] )?.*Outbound registration to '[^']+' with client '(?P<entity>[^']+)' successful`,
+	Kind:    KindRegistration,
+	State:   StateReachable,
+}
+
+// authFailureRule matches the failed REGISTER/INVITE authentications of pjsip
+// and chan_sip, used when the security rule is enabled. detail holds the
+// reason logged by Asterisk.
+var authFailureRule = config.RuleConfig{
+	Name:    "auth_failure",
+	Pattern: `(?:^\[(?P<timestamp>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\] )?.* failed for '(?:\[(?P<entity>[0-9A-Fa-f:.]+)\]|(?P<entity>[0-9.]+))(?::\d+)?'.* - (?P<detail>Wrong password|No matching (?:endpoint|peer) found|Failed to authenticate|Username/auth name mismatch|Not a local domain|Device does not match ACL|Peer is not supposed to register|ACL error.*)`,
+	Kind:    KindAuthFailure,
+}
+
+// Acknowledgements logged by the voice call dialplan: "ParseWatchdog ACK <incident> <number>"
+var ackRe = regexp.MustCompile(`ParseWatchdog ACK (\S+)(?: (\S+))?`)
+
+// Name of the user event raised by the voice call dialplan, read by the AMI
+// and ARI sources: UserEvent(ParseWatchdogAck,Incident: <incident>,Number: <number>)
+const ackUserEvent = "ParseWatchdogAck"
+
+// Rule is a compiled log rule with its detection threshold and window
+type Rule struct {
+	Name string
+	Kind string
+	// Threshold is the number of entities from which an alert is sent
+	Threshold int
+	// ThresholdPercent, when not zero, raises Threshold to that percentage
+	// of the endpoint inventory
+	ThresholdPercent float64
+	// Window is the time, from the first event, in which the entities are
+	// counted together. Zero groups only the events with the same timestamp.
+	Window time.Duration
+	// Severity is the severity of the alerts, raised to critical from
+	// CriticalThreshold entities
+	Severity          string
+	CriticalThreshold int
+
+	re              *regexp.Regexp
+	state           string
+	timestampLayout string
+}
+
+// Parser turns log lines into events using the configured rules
+type Parser struct {
+	rules    []*Rule
+	byName   map[string]*Rule
+	fallback *Rule
+	tenantRe *regexp.Regexp
+}
+
+// NewParser compiles and validates the configured rules. The built-in
+// endpoint state rule is used when none is configured.
+func NewParser(cfg *config.Config) (*Parser, error) {
+	ruleConfigs := cfg.Rules.Rules
+	if len(ruleConfigs) == 0 {
+		rule := defaultRule
+		rule.Threshold = cfg.Rules.Threshold
+		rule.ThresholdPercent = cfg.Rules.ThresholdPercent
+		rule.Window = cfg.Rules.Window
+		rule.Severity = cfg.Rules.Severity
+		rule.CriticalThreshold = cfg.Rules.CriticalThreshold
+		ruleConfigs = []config.RuleConfig{rule}
+	}
+	if len(cfg.Trunks.Names) > 0 && !hasKind(ruleConfigs, KindRegistration) {
+		for _, rule := range []config.RuleConfig{registrationRule, registeredRule} {
+			rule.Threshold = 1
+			ruleConfigs = append(ruleConfigs, rule)
+		}
+	}
+	if cfg.Security.Enabled && !hasKind(ruleConfigs, KindAuthFailure) {
+		rule := authFailureRule
+		rule.Threshold = cfg.Security.Threshold
+		rule.Window = cfg.Security.Window
+		ruleConfigs = append(ruleConfigs, rule)
+	}
+
+	p := &Parser{
+		byName: make(map[string]*Rule),
+		fallback: &Rule{
+			Name:              "default",
+			Kind:              KindEndpointState,
+			Threshold:         cfg.Rules.Threshold,
+			ThresholdPercent:  cfg.Rules.ThresholdPercent,
+			Window:            time.Duration(cfg.Rules.Window) * time.Second,
+			Severity:          cfg.Rules.Severity,
+			CriticalThreshold: cfg.Rules.CriticalThreshold,
+		},
+	}
+	if err := validateLimits(p.fallback.Name, cfg.Rules.Threshold, cfg.Rules.ThresholdPercent, cfg.Rules.Window); err != nil {
+		return nil, err
+	}
+	if cfg.Rules.TenantPattern != "" {
+		re, err := regexp.Compile(cfg.Rules.TenantPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tenant_pattern: %w", err)
+		}
+		if re.SubexpIndex("tenant") < 0 {
+			return nil, fmt.Errorf("tenant_pattern has no (?P<tenant>...) group")
+		}
+		p.tenantRe = re
+	}
+	for _, ruleConfig := range ruleConfigs {
+		rule, err := compileRule(ruleConfig)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, rule)
+		p.byName[rule.Name] = rule
+	}
+	return p, nil
+}
+
+// compileRule validates a rule and compiles its pattern
+func compileRule(rc config.RuleConfig) (*Rule, error) {
+	if rc.Pattern == "" {
+		return nil, fmt.Errorf("rule %s: pattern is required", rc.Name)
+	}
+	re, err := regexp.Compile(rc.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: invalid pattern: %w", rc.Name, err)
+	}
+	if re.SubexpIndex("entity") < 0 {
+		return nil, fmt.Errorf("rule %s: pattern has no (?P<entity>...) group", rc.Name)
+	}
+
+	rule := &Rule{
+		Name:              rc.Name,
+		Kind:              rc.Kind,
+		Threshold:         rc.Threshold,
+		ThresholdPercent:  rc.ThresholdPercent,
+		Window:            time.Duration(rc.Window) * time.Second,
+		Severity:          rc.Severity,
+		CriticalThreshold: rc.CriticalThreshold,
+		re:                re,
+		timestampLayout:   rc.TimestampLayout,
+	}
+	if rule.timestampLayout == "" {
+		rule.timestampLayout = TimestampLayout
+	}
+
+	switch rule.Kind {
+	case KindEndpointState, KindRegistration:
+	case KindAuthFailure:
+		// Every match is a failed attempt
+		rule.state = StateFailed
+	default:
+		return nil, fmt.Errorf("rule %s: unknown kind %q", rc.Name, rc.Kind)
+	}
+
+	// The state is fixed by the kind, by the rule or captured by the pattern
+	switch {
+	case rule.state != "":
+	case rc.State != "":
+		if rule.state = normalizeState(rc.State); rule.state == "" {
+			return nil, fmt.Errorf("rule %s: invalid state %q, expected Unreachable or Reachable", rc.Name, rc.State)
+		}
+	case re.SubexpIndex("state") < 0:
+		return nil, fmt.Errorf("rule %s: pattern has no (?P<state>...) group and no state is set", rc.Name)
+	}
+
+	if err := validateLimits(rc.Name, rc.Threshold, rc.ThresholdPercent, rc.Window); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// hasKind reports whether any of the rules produces events of kind
+func hasKind(rules []config.RuleConfig, kind string) bool {
+	for _, rule := range rules {
+		if rule.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLimits checks the thresholds and window of a rule
+func validateLimits(name string, threshold int, percent float64, window int) error {
+	if threshold < 1 {
+		return fmt.Errorf("rule %s: threshold must be at least 1", name)
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("rule %s: threshold_percent must be between 0 and 100", name)
+	}
+	if window < 0 {
+		return fmt.Errorf("rule %s: window must not be negative", name)
+	}
+	return nil
+}
+
+// normalizeState maps a captured state to the event states
+func normalizeState(value string) string {
+	switch {
+	case strings.EqualFold(value, StateUnreachable):
+		return StateUnreachable
+	case strings.EqualFold(value, StateReachable), strings.EqualFold(value, "Lagged"):
+		return StateReachable
+	default:
+		return ""
+	}
+}
+
+// Rule returns the rule named name, or the default rule for the events that
+// were not produced by a log rule, e.g. those from AMI or ARI
+func (p *Parser) Rule(name string) *Rule {
+	if rule, ok := p.byName[name]; ok {
+		return rule
+	}
+	return p.fallback
+}
+
+// Tenant returns the tenant of an endpoint name using tenant_pattern, or an
+// empty string when it does not match. The pattern may have several tenant
+// groups in alternatives, e.g. for both acme-1001 and 1001@acme.
+func (p *Parser) Tenant(entity string) string {
+	if p.tenantRe == nil {
+		return ""
+	}
+	match := p.tenantRe.FindStringSubmatch(entity)
+	if match == nil {
+		return ""
+	}
+	for i, name := range p.tenantRe.SubexpNames() {
+		if name == "tenant" && match[i] != "" {
+			return match[i]
+		}
+	}
+	return ""
+}
+
+// Parse extracts the event of a log line or message, if any. at is the event
+// time used when the rule captures no timestamp.
+func (p *Parser) Parse(line string, at time.Time) (Event, bool) {
+	if ack := ackRe.FindStringSubmatch(line); ack != nil {
+		return Event{Entity: ack[1], State: StateAcknowledged, Detail: ack[2]}, true
+	}
+
+	for _, rule := range p.rules {
+		if event, ok := rule.match(line, at); ok {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+// match applies the rule to line
+func (r *Rule) match(line string, at time.Time) (Event, bool) {
+	match := r.re.FindStringSubmatch(line)
+	if match == nil {
+		return Event{}, false
+	}
+	// A group name may be repeated in alternatives, the first one that
+	// matched is used
+	group := func(name string) string {
+		for i, subexp := range r.re.SubexpNames() {
+			if subexp == name && match[i] != "" {
+				return strings.TrimSpace(match[i])
+			}
+		}
+		return ""
+	}
+
+	event := Event{Entity: group("entity"), Tenant: group("tenant"), Detail: group("detail"), Kind: r.Kind, Rule: r.Name, State: r.state}
+	if event.Entity == "" {
+		return Event{}, false
+	}
+	if event.State == "" {
+		if event.State = normalizeState(group("state")); event.State == "" {
+			return Event{}, false
+		}
+	}
+
+	// Qualify round-trip time in milliseconds
+	if value, err := strconv.ParseFloat(group("rtt"), 64); err == nil && event.State == StateReachable {
+		event.RTT = time.Duration(value * float64(time.Millisecond))
+	}
+
+	event.Timestamp = at.Local().Format(TimestampLayout)
+	if value := group("timestamp"); value != "" {
+		if timestamp, err := time.ParseInLocation(r.timestampLayout, value, time.Local); err == nil {
+			event.Timestamp = timestamp.Format(TimestampLayout)
+		}
+	}
+	return event, true
+}
@@ -0,0 +1,68 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// TimestampLayout is the layout of Event.Timestamp, the same used by the
+// Asterisk full log
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// Endpoint states carried by the events
+const (
+	StateUnreachable  = "Unreachable"
+	StateReachable    = "Reachable"
+	StateAcknowledged = "Acknowledged"
+	StateFailed       = "Failed"
+)
+
+// Event is an endpoint state change read from any of the inputs. For
+// acknowledgements Entity holds the incident ID and Detail who acknowledged.
+// Rule is the name of the log rule that produced the event, if any, and
+// Tenant the tenant the entity belongs to on multi-tenant PBXs. RTT is the
+// qualify round-trip time reported with a Reachable state, if any.
+type Event struct {
+	Timestamp string
+	Entity    string
+	Tenant    string
+	State     string
+	Detail    string
+	Kind      string
+	Rule      string
+	RTT       time.Duration
+}
+
+// Source produces events until the process exits
+type Source interface {
+	Run(events chan<- Event)
+}
+
+// New creates the source configured with name. The log based sources use
+// parser to turn lines into events.
+func New(cfg *config.Config, parser *Parser, name string) (Source, error) {
+	switch name {
+	case "file":
+		return NewFileSource(cfg, parser)
+	case "ami":
+		return NewAMISource(cfg), nil
+	case "ari":
+		return NewARISource(cfg), nil
+	case "journald":
+		return NewJournaldSource(cfg, parser)
+	case "syslog":
+		return NewSyslogSource(cfg, parser)
+	default:
+		return nil, fmt.Errorf("unknown input source %q", name)
+	}
+}
+
+// logMessage logs message when the debug level is at least level
+func logMessage(cfg *config.Config, level int, message string) {
+	if cfg.Debug.DebugLevel >= level {
+		log.Println(message)
+	}
+}
@@ -0,0 +1,226 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/parsewatchdog/config"
+)
+
+// syslogMaxMessage is the largest syslog message accepted
+const syslogMaxMessage = 64 * 1024
+
+// SyslogSource receives the Asterisk messages forwarded by a syslog daemon,
+// in RFC 3164 or RFC 5424 format, over UDP or TCP
+type SyslogSource struct {
+	config   *config.Config
+	parser   *Parser
+	packet   net.PacketConn
+	listener net.Listener
+	allowed  []*net.IPNet
+}
+
+// NewSyslogSource starts listening on the configured address
+func NewSyslogSource(cfg *config.Config, parser *Parser) (*SyslogSource, error) {
+	s := &SyslogSource{config: cfg, parser: parser}
+
+	for _, entry := range cfg.Input.SyslogAllowedSources {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog allowed source %q: %w", entry, err)
+		}
+		s.allowed = append(s.allowed, network)
+	}
+
+	var err error
+	if cfg.Input.SyslogNetwork == "tcp" {
+		s.listener, err = net.Listen("tcp", cfg.Input.SyslogAddress)
+	} else {
+		s.packet, err = net.ListenPacket("udp", cfg.Input.SyslogAddress)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for syslog messages: %w", err)
+	}
+	return s, nil
+}
+
+// Run receives messages until the process exits
+func (s *SyslogSource) Run(events chan<- Event) {
+	logMessage(s.config, 1, fmt.Sprintf("Syslog source listening on %s/%s", s.config.Input.SyslogAddress, s.config.Input.SyslogNetwork))
+
+	if s.packet != nil {
+		s.receivePackets(events)
+		return
+	}
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			logMessage(s.config, 1, fmt.Sprintf("Syslog source: %v", err))
+			time.Sleep(time.Second)
+			continue
+		}
+		if !s.allows(conn.RemoteAddr()) {
+			logMessage(s.config, 1, fmt.Sprintf("Syslog source: connection from %s not allowed, closed", conn.RemoteAddr()))
+			conn.Close()
+			continue
+		}
+		go s.receiveStream(conn, events)
+	}
+}
+
+// receivePackets reads one message per UDP datagram
+func (s *SyslogSource) receivePackets(events chan<- Event) {
+	buf := make([]byte, syslogMaxMessage)
+	for {
+		n, addr, err := s.packet.ReadFrom(buf)
+		if err != nil {
+			logMessage(s.config, 1, fmt.Sprintf("Syslog source: %v", err))
+			time.Sleep(time.Second)
+			continue
+		}
+		if !s.allows(addr) {
+			logMessage(s.config, 2, fmt.Sprintf("Syslog source: message from %s not allowed, dropped", addr))
+			continue
+		}
+		s.handle(string(buf[:n]), events)
+	}
+}
+
+// receiveStream reads the messages of a TCP connection, framed by new lines
+// or by octet counting (RFC 6587)
+func (s *SyslogSource) receiveStream(conn net.Conn, events chan<- Event) {
+	defer conn.Close()
+
+	reader := bufio.NewReaderSize(conn, syslogMaxMessage)
+	for {
+		first, err := reader.Peek(1)
+		if err != nil {
+			return
+		}
+
+		var frame string
+		if first[0] >= '0' && first[0] <= '9' {
+			size, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			length, err := strconv.Atoi(strings.TrimSpace(size))
+			if err != nil || length <= 0 || length > syslogMaxMessage {
+				logMessage(s.config, 1, fmt.Sprintf("Syslog source: invalid frame length from %s", conn.RemoteAddr()))
+				return
+			}
+			buf := make([]byte, length)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			frame = string(buf)
+		} else {
+			frame, err = reader.ReadString('\n')
+			if err != nil && frame == "" {
+				return
+			}
+		}
+		s.handle(frame, events)
+	}
+}
+
+// allows reports whether the peer address is in the allowed sources, as the
+// messages can raise alerts, acknowledge incidents and trigger remediation
+func (s *SyslogSource) allows(addr net.Addr) bool {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.TCPAddr:
+		ip = a.IP
+	default:
+		return false
+	}
+	for _, network := range s.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// handle sends the event of a syslog message, if any
+func (s *SyslogSource) handle(frame string, events chan<- Event) {
+	message := syslogMessage(strings.TrimRight(frame, "\r\n\x00"))
+	if event, ok := s.parser.Parse(message, time.Now()); ok {
+		logMessage(s.config, 2, fmt.Sprintf("Reading syslog message: %s", message))
+		events <- event
+	}
+}
+
+// syslogMessage strips the RFC 5424 or RFC 3164 header of a syslog message
+// and returns its free-form part
+func syslogMessage(frame string) string {
+	// <PRI>
+	if strings.HasPrefix(frame, "<") {
+		if end := strings.IndexByte(frame, '>'); end > 0 && end <= 4 {
+			frame = frame[end+1:]
+		}
+	}
+
+	// RFC 5424: VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+	if strings.HasPrefix(frame, "1 ") {
+		fields := strings.SplitN(frame, " ", 7)
+		if len(fields) < 7 {
+			return ""
+		}
+		rest := fields[6]
+		if strings.HasPrefix(rest, "-") {
+			rest = rest[1:]
+		} else {
+			rest = skipStructuredData(rest)
+		}
+		return strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	}
+
+	// RFC 3164: TIMESTAMP HOSTNAME TAG: MSG
+	if len(frame) > 16 {
+		if _, err := time.Parse(time.Stamp, frame[:15]); err == nil {
+			frame = frame[16:]
+			if _, rest, ok := strings.Cut(frame, " "); ok {
+				frame = rest
+			}
+		}
+	}
+	if tag, rest, ok := strings.Cut(frame, ": "); ok && !strings.ContainsAny(tag, " ") {
+		return rest
+	}
+	return frame
+}
+
+// skipStructuredData removes the RFC 5424 structured data elements, which
+// may contain escaped "]" characters in their values
+func skipStructuredData(s string) string {
+	for strings.HasPrefix(s, "[") {
+		escaped := false
+		end := -1
+		for i := 1; i < len(s); i++ {
+			switch {
+			case escaped:
+				escaped = false
+			case s[i] == '\\':
+				escaped = true
+			case s[i] == ']':
+				end = i
+			}
+			if end >= 0 {
+				break
+			}
+		}
+		if end < 0 {
+			return ""
+		}
+		s = s[end+1:]
+	}
+	return s
+}